@@ -1,13 +1,46 @@
 package benchmarks
 
 import (
+	"BST/locks"
 	"BST/trees"
+	"math/rand"
 	"sync"
 	"testing"
 )
 
 const countElem = 10_000
 
+// treeConstructors lists every tree, each benchmark runs on all of them. The
+// trees without locks ignore the options.
+var treeConstructors = []struct {
+	name   string
+	create func(opts ...trees.Option) trees.Tree[int, int]
+}{
+	{"Grained Tree", func(opts ...trees.Option) trees.Tree[int, int] { return trees.NewGrainedSyncTree[int, int](opts...) }},
+	{"Fine-grained Tree", func(opts ...trees.Option) trees.Tree[int, int] {
+		return trees.NewFineGrainedSyncTree[int, int](opts...)
+	}},
+	{"RW Grained Tree", func(opts ...trees.Option) trees.Tree[int, int] {
+		return trees.NewRWGrainedSyncTree[int, int](opts...)
+	}},
+	{"RW Fine-grained Tree", func(opts ...trees.Option) trees.Tree[int, int] {
+		return trees.NewRWFineGrainedSyncTree[int, int](opts...)
+	}},
+	{"Optimistic Tree", func(opts ...trees.Option) trees.Tree[int, int] { return trees.NewOptimisticSyncTree[int, int](opts...) }},
+	{"Lock-free Tree", func(...trees.Option) trees.Tree[int, int] { return trees.NewLockFreeTree[int, int]() }},
+	{"AVL Tree", func(...trees.Option) trees.Tree[int, int] { return trees.NewAVLTree[int, int]() }},
+	{"Skip List", func(...trees.Option) trees.Tree[int, int] { return trees.NewSkipList[int, int]() }},
+}
+
+// takesLocks reports whether the tree makes its locks with WithLockFactory.
+func takesLocks(tree trees.Tree[int, int]) bool {
+	switch tree.(type) {
+	case *trees.GrainedSyncTree[int, int], *trees.FineGrainedSyncTree[int, int], *trees.OptimisticTree[int, int]:
+		return true
+	}
+	return false
+}
+
 func SeqInsert(t trees.Tree[int, int]) {
 	for i := 0; i < countElem; i++ {
 		t.Insert(i, i)
@@ -21,49 +54,23 @@ func SeqRemove(t trees.Tree[int, int]) {
 }
 
 func BenchmarkSeqInsert(b *testing.B) {
-	b.Run("Grained Tree", func(b *testing.B) {
-		for i := 0; i < b.N; i++ {
-			tree := trees.NewGrainedSyncTree[int, int]()
-			SeqInsert(tree)
-		}
-	})
-
-	b.Run("Fine-grained Tree", func(b *testing.B) {
-		for i := 0; i < b.N; i++ {
-			tree := trees.NewFineGrainedSyncTree[int, int]()
-			SeqInsert(tree)
-		}
-	})
-
-	b.Run("Optimistic Tree", func(b *testing.B) {
-		for i := 0; i < b.N; i++ {
-			tree := trees.NewOptimisticSyncTree[int, int]()
-			SeqInsert(tree)
-		}
-	})
+	for _, tc := range treeConstructors {
+		b.Run(tc.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				SeqInsert(tc.create())
+			}
+		})
+	}
 }
 
 func BenchmarkSeqRemove(b *testing.B) {
-	b.Run("Grained Tree", func(b *testing.B) {
-		for i := 0; i < b.N; i++ {
-			tree := trees.NewGrainedSyncTree[int, int]()
-			SeqRemove(tree)
-		}
-	})
-
-	b.Run("Fine-grained Tree", func(b *testing.B) {
-		for i := 0; i < b.N; i++ {
-			tree := trees.NewFineGrainedSyncTree[int, int]()
-			SeqRemove(tree)
-		}
-	})
-
-	b.Run("Optimistic Tree", func(b *testing.B) {
-		for i := 0; i < b.N; i++ {
-			tree := trees.NewOptimisticSyncTree[int, int]()
-			SeqRemove(tree)
-		}
-	})
+	for _, tc := range treeConstructors {
+		b.Run(tc.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				SeqRemove(tc.create())
+			}
+		})
+	}
 }
 
 func ConcurrentInsert(t trees.Tree[int, int], wg *sync.WaitGroup) {
@@ -85,36 +92,184 @@ func ConcurrentRemove(t trees.Tree[int, int], wg *sync.WaitGroup) {
 }
 
 func BenchmarkConcurrentInsertAndRemove(b *testing.B) {
-	b.Run("Grained Tree", func(b *testing.B) {
-		for i := 0; i < b.N; i++ {
-			tree := trees.NewGrainedSyncTree[int, int]()
-			wg := sync.WaitGroup{}
-			wg.Add(countElem * 10 * 2)
-			go ConcurrentInsert(tree, &wg)
-			go ConcurrentRemove(tree, &wg)
-			wg.Wait()
+	for _, tc := range treeConstructors {
+		b.Run(tc.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				tree := tc.create()
+				wg := sync.WaitGroup{}
+				wg.Add(countElem * 10 * 2)
+				go ConcurrentInsert(tree, &wg)
+				go ConcurrentRemove(tree, &wg)
+				wg.Wait()
+			}
+		})
+	}
+}
+
+// randKeys is a fixed permutation of 0..countElem-1, so every run and every
+// tree gets the same random-key workload.
+var randKeys = rand.New(rand.NewSource(1)).Perm(countElem)
+
+func RandInsert(t trees.Tree[int, int]) {
+	for _, key := range randKeys {
+		t.Insert(key, key)
+	}
+}
+
+func FindAll(t trees.Tree[int, int], keys []int) {
+	for _, key := range keys {
+		t.Find(key)
+	}
+}
+
+func BenchmarkRandInsert(b *testing.B) {
+	for _, tc := range treeConstructors {
+		b.Run(tc.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				RandInsert(tc.create())
+			}
+		})
+	}
+}
+
+func BenchmarkSeqFind(b *testing.B) {
+	for _, tc := range treeConstructors {
+		b.Run(tc.name, func(b *testing.B) {
+			tree := tc.create()
+			SeqInsert(tree)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				FindAll(tree, randKeys)
+			}
+		})
+	}
+}
+
+func BenchmarkRandFind(b *testing.B) {
+	for _, tc := range treeConstructors {
+		b.Run(tc.name, func(b *testing.B) {
+			tree := tc.create()
+			RandInsert(tree)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				FindAll(tree, randKeys)
+			}
+		})
+	}
+}
+
+func ConcurrentRandInsert(t trees.Tree[int, int], goroutineCount int) {
+	wg := sync.WaitGroup{}
+	wg.Add(goroutineCount)
+	for g := 0; g < goroutineCount; g++ {
+		go func(g int) {
+			defer wg.Done()
+			for j := g; j < countElem; j += goroutineCount {
+				t.Insert(randKeys[j], j)
+			}
+		}(g)
+	}
+	wg.Wait()
+}
+
+func BenchmarkConcurrentRandInsert(b *testing.B) {
+	for _, tc := range treeConstructors {
+		b.Run(tc.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				ConcurrentRandInsert(tc.create(), 8)
+			}
+		})
+	}
+}
+
+// ConcurrentMixed runs countElem*10 operations on random keys split between
+// goroutineCount goroutines. insertPercent and removePercent of them are
+// inserts and removes, the rest are finds.
+func ConcurrentMixed(t trees.Tree[int, int], goroutineCount, insertPercent, removePercent int) {
+	wg := sync.WaitGroup{}
+	wg.Add(goroutineCount)
+	for g := 0; g < goroutineCount; g++ {
+		go func(g int) {
+			defer wg.Done()
+			r := rand.New(rand.NewSource(int64(g)))
+			for j := g; j < countElem*10; j += goroutineCount {
+				key := randKeys[j%countElem]
+				switch op := r.Intn(100); {
+				case op < removePercent:
+					t.Remove(key)
+				case op < removePercent+insertPercent:
+					t.Insert(key, j)
+				default:
+					t.Find(key)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+}
+
+var readMostlyMixes = []struct {
+	name                         string
+	insertPercent, removePercent int
+}{
+	{"90-9-1", 9, 1},
+	{"99-1-0", 1, 0},
+}
+
+// BenchmarkReadMostly measures read/insert/remove mixes on a tree prefilled
+// with every key.
+func BenchmarkReadMostly(b *testing.B) {
+	for _, mix := range readMostlyMixes {
+		for _, tc := range treeConstructors {
+			b.Run(mix.name+"/"+tc.name, func(b *testing.B) {
+				tree := tc.create()
+				RandInsert(tree)
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					ConcurrentMixed(tree, 8, mix.insertPercent, mix.removePercent)
+				}
+			})
 		}
-	})
-
-	b.Run("Fine-grained Tree", func(b *testing.B) {
-		for i := 0; i < b.N; i++ {
-			tree := trees.NewFineGrainedSyncTree[int, int]()
-			wg := sync.WaitGroup{}
-			wg.Add(countElem * 10 * 2)
-			go ConcurrentInsert(tree, &wg)
-			go ConcurrentRemove(tree, &wg)
-			wg.Wait()
+	}
+}
+
+var lockConstructors = []struct {
+	name    string
+	newLock func() sync.Locker
+}{
+	{"Mutex", func() sync.Locker { return &sync.Mutex{} }},
+	{"TAS", func() sync.Locker { return locks.NewTASLock() }},
+	{"TTAS", func() sync.Locker { return locks.NewTTASLock() }},
+	{"Backoff", func() sync.Locker { return locks.NewBackoffLock(locks.DefaultMinDelay, locks.DefaultMaxDelay) }},
+	{"Ticket", func() sync.Locker { return locks.NewTicketLock() }},
+	{"CLH", func() sync.Locker { return locks.NewCLHLock() }},
+	{"MCS", func() sync.Locker { return locks.NewMCSLock() }},
+}
+
+// BenchmarkLocks runs the concurrent insert and the 90/9/1 workloads on the
+// lock-based trees with every lock type.
+func BenchmarkLocks(b *testing.B) {
+	for _, tc := range treeConstructors {
+		if !takesLocks(tc.create()) {
+			continue
 		}
-	})
-
-	b.Run("Optimistic Tree", func(b *testing.B) {
-		for i := 0; i < b.N; i++ {
-			tree := trees.NewOptimisticSyncTree[int, int]()
-			wg := sync.WaitGroup{}
-			wg.Add(countElem * 10 * 2)
-			go ConcurrentInsert(tree, &wg)
-			go ConcurrentRemove(tree, &wg)
-			wg.Wait()
+		for _, lc := range lockConstructors {
+			option := trees.WithLockFactory(lc.newLock)
+
+			b.Run("RandInsert/"+tc.name+"/"+lc.name, func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					ConcurrentRandInsert(tc.create(option), 8)
+				}
+			})
+
+			b.Run("90-9-1/"+tc.name+"/"+lc.name, func(b *testing.B) {
+				tree := tc.create(option)
+				RandInsert(tree)
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					ConcurrentMixed(tree, 8, 9, 1)
+				}
+			})
 		}
-	})
+	}
 }
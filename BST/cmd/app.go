@@ -1,48 +1,172 @@
+// Command app runs a configurable workload against one of the trees and
+// reports its throughput and latency percentiles.
+//
+//	go run ./cmd -tree skip-list -goroutines 8 -duration 10s -mix 90/9/1 -dist zipf
 package main
 
 import (
+	"BST/locks"
 	"BST/trees"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 )
 
+var treeConstructors = map[string]func(opts ...trees.Option) trees.Tree[int, int]{
+	"grained":    func(opts ...trees.Option) trees.Tree[int, int] { return trees.NewGrainedSyncTree[int, int](opts...) },
+	"rw-grained": func(opts ...trees.Option) trees.Tree[int, int] { return trees.NewRWGrainedSyncTree[int, int](opts...) },
+	"fine-grained": func(opts ...trees.Option) trees.Tree[int, int] {
+		return trees.NewFineGrainedSyncTree[int, int](opts...)
+	},
+	"rw-fine-grained": func(opts ...trees.Option) trees.Tree[int, int] {
+		return trees.NewRWFineGrainedSyncTree[int, int](opts...)
+	},
+	"optimistic": func(opts ...trees.Option) trees.Tree[int, int] { return trees.NewOptimisticSyncTree[int, int](opts...) },
+	"lock-free":  func(...trees.Option) trees.Tree[int, int] { return trees.NewLockFreeTree[int, int]() },
+	"avl":        func(...trees.Option) trees.Tree[int, int] { return trees.NewAVLTree[int, int]() },
+	"skip-list":  func(...trees.Option) trees.Tree[int, int] { return trees.NewSkipList[int, int]() },
+}
+
+// lockableTrees accept trees.WithLockFactory with the -lock lock, the others
+// have no locks or, like the AVL tree, do not take options.
+var lockableTrees = map[string]bool{
+	"grained":         true,
+	"rw-grained":      true,
+	"fine-grained":    true,
+	"rw-fine-grained": true,
+	"optimistic":      true,
+}
+
+var lockConstructors = map[string]func() sync.Locker{
+	"mutex":   func() sync.Locker { return &sync.Mutex{} },
+	"rwmutex": func() sync.Locker { return &sync.RWMutex{} },
+	"tas":     func() sync.Locker { return locks.NewTASLock() },
+	"ttas":    func() sync.Locker { return locks.NewTTASLock() },
+	"backoff": func() sync.Locker { return locks.NewBackoffLock(locks.DefaultMinDelay, locks.DefaultMaxDelay) },
+	"ticket":  func() sync.Locker { return locks.NewTicketLock() },
+	"clh":     func() sync.Locker { return locks.NewCLHLock() },
+	"mcs":     func() sync.Locker { return locks.NewMCSLock() },
+}
+
+type config struct {
+	tree         string
+	lock         string
+	goroutines   int
+	duration     time.Duration
+	ops          int64
+	mix          string
+	findPercent  int
+	insPercent   int
+	remPercent   int
+	keys         int
+	distribution string
+	zipfS        float64
+	prefill      float64
+	format       string
+	seed         int64
+}
+
 func main() {
-	tree := trees.NewOptimisticSyncTree[int, int]()
-	wg := sync.WaitGroup{}
-	wg.Wait()
-	for i := 1; i <= 10; i++ {
-		wg.Add(1)
-		go func(j int) {
-			defer wg.Done()
-			tree.Insert(j, j*10)
-		}(i)
-	}
-	wg.Wait()
-	//for i := 10; i > 0; i-- {
-	//	wg.Add(1)
-	//	go func(j int) {
-	//		defer wg.Done()
-	//		v, exist := tree.Find(j)
-	//		fmt.Printf("key = %d, value = %d, exist = %t\n", j, v, exist)
-	//	}(i)
-	//}
-	//wg.Wait()
-	//fmt.Println("REMOVE ALL")
-	//for i := 1; i <= 10; i++ {
-	//	wg.Add(1)
-	//	go func(j int) {
-	//		defer wg.Done()
-	//		tree.Remove(j)
-	//	}(i)
-	//}
-	//wg.Wait()
-	//
-	//for i := 10; i > 0; i-- {
-	//	wg.Add(1)
-	//	go func(j int) {
-	//		defer wg.Done()
-	//		v, exist := tree.Find(j)
-	//		fmt.Printf("key = %d, value = %d, exist = %t\n", j, v, exist)
-	//	}(i)
-	//}
-	//wg.Wait()
+	cfg, err := parseFlags(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "app:", err)
+		os.Exit(2)
+	}
+
+	var opts []trees.Option
+	if cfg.lock != "" {
+		opts = append(opts, trees.WithLockFactory(lockConstructors[cfg.lock]))
+	}
+	tree := treeConstructors[cfg.tree](opts...)
+	res := run(tree, cfg)
+
+	if err := writeReport(os.Stdout, cfg, res); err != nil {
+		fmt.Fprintln(os.Stderr, "app:", err)
+		os.Exit(1)
+	}
+}
+
+func parseFlags(args []string) (cfg config, err error) {
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.StringVar(&cfg.tree, "tree", "fine-grained", "tree implementation: "+names(treeConstructors))
+	fs.StringVar(&cfg.lock, "lock", "", "lock of the grained, fine-grained and optimistic trees and their rw variants, "+
+		"empty for mutex or rwmutex in the rw ones: "+names(lockConstructors))
+	fs.IntVar(&cfg.goroutines, "goroutines", runtime.GOMAXPROCS(0), "number of goroutines")
+	fs.DurationVar(&cfg.duration, "duration", 5*time.Second, "duration of the run, ignored if -ops is set")
+	fs.Int64Var(&cfg.ops, "ops", 0, "total number of operations; 0 runs for -duration")
+	fs.StringVar(&cfg.mix, "mix", "90/9/1", "find/insert/remove percentages")
+	fs.IntVar(&cfg.keys, "keys", 100_000, "keys are drawn from [0, keys)")
+	fs.StringVar(&cfg.distribution, "dist", "uniform", "key distribution: uniform, zipf or sequential")
+	fs.Float64Var(&cfg.zipfS, "zipf-s", 1.1, "exponent of the Zipfian distribution, must be > 1")
+	fs.Float64Var(&cfg.prefill, "prefill", 0.5, "share of the key range inserted before the run")
+	fs.StringVar(&cfg.format, "format", "text", "report format: text, csv or json")
+	fs.Int64Var(&cfg.seed, "seed", 1, "seed of the key generators")
+	if err = fs.Parse(args); err != nil {
+		return
+	}
+
+	if _, ok := treeConstructors[cfg.tree]; !ok {
+		return cfg, fmt.Errorf("unknown tree %q", cfg.tree)
+	}
+	if cfg.lock != "" {
+		if _, ok := lockConstructors[cfg.lock]; !ok {
+			return cfg, fmt.Errorf("unknown lock %q", cfg.lock)
+		}
+		if !lockableTrees[cfg.tree] {
+			return cfg, fmt.Errorf("-lock does not apply to the %s tree", cfg.tree)
+		}
+	}
+	if cfg.findPercent, cfg.insPercent, cfg.remPercent, err = parseMix(cfg.mix); err != nil {
+		return
+	}
+	switch {
+	case cfg.goroutines < 1:
+		return cfg, errors.New("-goroutines must be positive")
+	case cfg.ops < 0 || (cfg.ops == 0 && cfg.duration <= 0):
+		return cfg, errors.New("either -ops or -duration must be positive")
+	case cfg.keys < 1:
+		return cfg, errors.New("-keys must be positive")
+	case cfg.prefill < 0 || cfg.prefill > 1:
+		return cfg, errors.New("-prefill must be in [0, 1]")
+	case cfg.distribution != "uniform" && cfg.distribution != "zipf" && cfg.distribution != "sequential":
+		return cfg, fmt.Errorf("unknown distribution %q", cfg.distribution)
+	case cfg.distribution == "zipf" && cfg.zipfS <= 1:
+		return cfg, errors.New("-zipf-s must be greater than 1")
+	case cfg.format != "text" && cfg.format != "csv" && cfg.format != "json":
+		return cfg, fmt.Errorf("unknown format %q", cfg.format)
+	}
+	return cfg, nil
+}
+
+func parseMix(mix string) (find, insert, remove int, err error) {
+	parts := strings.Split(mix, "/")
+	if len(parts) != 3 {
+		return 0, 0, 0, fmt.Errorf("-mix %q is not find/insert/remove", mix)
+	}
+	var percents [3]int
+	for i, part := range parts {
+		if percents[i], err = strconv.Atoi(part); err != nil || percents[i] < 0 {
+			return 0, 0, 0, fmt.Errorf("-mix %q has a bad percentage %q", mix, part)
+		}
+	}
+	if percents[0]+percents[1]+percents[2] != 100 {
+		return 0, 0, 0, fmt.Errorf("-mix %q does not sum to 100", mix)
+	}
+	return percents[0], percents[1], percents[2], nil
+}
+
+func names[V any](m map[string]V) string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ", ")
 }
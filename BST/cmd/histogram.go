@@ -0,0 +1,65 @@
+package main
+
+import "math/bits"
+
+// subBucketBits sets the precision of histogram: every power of two is split
+// into 2^subBucketBits buckets, so a recorded latency is off by less than 2%.
+const subBucketBits = 6
+
+// histogram counts latencies in nanoseconds on a log-linear scale, which
+// keeps its size fixed however long the run is.
+type histogram struct {
+	counts [(64 - subBucketBits) << subBucketBits]int64
+	total  int64
+	max    int64
+}
+
+func bucketOf(ns int64) int {
+	if ns < 1<<subBucketBits {
+		return int(max(ns, 0))
+	}
+	shift := bits.Len64(uint64(ns)) - subBucketBits - 1
+	mantissa := int(ns >> shift)
+	return (shift+1)<<subBucketBits + mantissa - 1<<subBucketBits
+}
+
+// lowerBound returns the smallest latency counted in the bucket.
+func lowerBound(bucket int) int64 {
+	if bucket < 1<<subBucketBits {
+		return int64(bucket)
+	}
+	shift := bucket>>subBucketBits - 1
+	mantissa := bucket&(1<<subBucketBits-1) + 1<<subBucketBits
+	return int64(mantissa) << shift
+}
+
+func (h *histogram) record(ns int64) {
+	h.counts[bucketOf(ns)]++
+	h.total++
+	h.max = max(h.max, ns)
+}
+
+func (h *histogram) merge(other *histogram) {
+	for i, count := range other.counts {
+		h.counts[i] += count
+	}
+	h.total += other.total
+	h.max = max(h.max, other.max)
+}
+
+// percentile returns the lower bound of the bucket holding the p-th
+// percentile, 0 < p <= 100.
+func (h *histogram) percentile(p float64) int64 {
+	if h.total == 0 {
+		return 0
+	}
+	rank := int64(p / 100 * float64(h.total))
+	var seen int64
+	for bucket, count := range h.counts {
+		seen += count
+		if seen > rank {
+			return min(lowerBound(bucket), h.max)
+		}
+	}
+	return h.max
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+)
+
+var percentiles = []float64{50, 90, 99, 99.9}
+
+type latencyReport struct {
+	Op          string           `json:"op"`
+	Count       int64            `json:"count"`
+	Percentiles map[string]int64 `json:"percentiles_ns"`
+	Max         int64            `json:"max_ns"`
+}
+
+type report struct {
+	Tree         string          `json:"tree"`
+	Lock         string          `json:"lock,omitempty"`
+	Goroutines   int             `json:"goroutines"`
+	Mix          string          `json:"mix"`
+	Keys         int             `json:"keys"`
+	Distribution string          `json:"distribution"`
+	Prefill      float64         `json:"prefill"`
+	Seconds      float64         `json:"seconds"`
+	Ops          int64           `json:"ops"`
+	Throughput   float64         `json:"ops_per_second"`
+	Latency      []latencyReport `json:"latency"`
+}
+
+func percentileName(p float64) string {
+	return "p" + strconv.FormatFloat(p, 'f', -1, 64)
+}
+
+func newReport(cfg config, res *result) report {
+	rep := report{
+		Tree:         cfg.tree,
+		Lock:         cfg.lock,
+		Goroutines:   cfg.goroutines,
+		Mix:          cfg.mix,
+		Keys:         cfg.keys,
+		Distribution: cfg.distribution,
+		Prefill:      cfg.prefill,
+		Seconds:      res.elapsed.Seconds(),
+		Ops:          res.ops(),
+	}
+	rep.Throughput = float64(rep.Ops) / rep.Seconds
+
+	var all histogram
+	for kind := range res.latencies {
+		all.merge(&res.latencies[kind])
+	}
+	for kind := opFind; kind <= opCount; kind++ {
+		h, name := &all, "all"
+		if kind < opCount {
+			h, name = &res.latencies[kind], opNames[kind]
+		}
+		if h.total == 0 {
+			continue
+		}
+		lat := latencyReport{Op: name, Count: h.total, Percentiles: make(map[string]int64), Max: h.max}
+		for _, p := range percentiles {
+			lat.Percentiles[percentileName(p)] = h.percentile(p)
+		}
+		rep.Latency = append(rep.Latency, lat)
+	}
+	return rep
+}
+
+func writeReport(w io.Writer, cfg config, res *result) error {
+	rep := newReport(cfg, res)
+	switch cfg.format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rep)
+	case "csv":
+		return writeCSV(w, rep)
+	default:
+		return writeText(w, rep)
+	}
+}
+
+// writeCSV writes one row per operation kind, every row repeats the
+// parameters of the run so that rows of several runs can be concatenated.
+func writeCSV(w io.Writer, rep report) error {
+	out := csv.NewWriter(w)
+	header := []string{"tree", "lock", "goroutines", "mix", "keys", "distribution", "prefill",
+		"seconds", "ops", "ops_per_second", "op", "count"}
+	for _, p := range percentiles {
+		header = append(header, percentileName(p)+"_ns")
+	}
+	header = append(header, "max_ns")
+	if err := out.Write(header); err != nil {
+		return err
+	}
+
+	for _, lat := range rep.Latency {
+		row := []string{rep.Tree, rep.Lock, strconv.Itoa(rep.Goroutines), rep.Mix, strconv.Itoa(rep.Keys),
+			rep.Distribution, strconv.FormatFloat(rep.Prefill, 'f', -1, 64),
+			strconv.FormatFloat(rep.Seconds, 'f', 3, 64), strconv.FormatInt(rep.Ops, 10),
+			strconv.FormatFloat(rep.Throughput, 'f', 0, 64), lat.Op, strconv.FormatInt(lat.Count, 10)}
+		for _, p := range percentiles {
+			row = append(row, strconv.FormatInt(lat.Percentiles[percentileName(p)], 10))
+		}
+		row = append(row, strconv.FormatInt(lat.Max, 10))
+		if err := out.Write(row); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+func writeText(w io.Writer, rep report) error {
+	tree := rep.Tree
+	if rep.Lock != "" {
+		tree += " (" + rep.Lock + " locks)"
+	}
+	fmt.Fprintf(w, "tree %s, %d goroutines, mix %s, %d keys %s, prefill %.2f\n",
+		tree, rep.Goroutines, rep.Mix, rep.Keys, rep.Distribution, rep.Prefill)
+	fmt.Fprintf(w, "%d ops in %.3fs: %.0f ops/s\n\n", rep.Ops, rep.Seconds, rep.Throughput)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(tw, "op\tcount\t")
+	for _, p := range percentiles {
+		fmt.Fprintf(tw, "%s\t", percentileName(p))
+	}
+	fmt.Fprintln(tw, "max\t")
+	for _, lat := range rep.Latency {
+		fmt.Fprintf(tw, "%s\t%d\t", lat.Op, lat.Count)
+		for _, p := range percentiles {
+			fmt.Fprintf(tw, "%dns\t", lat.Percentiles[percentileName(p)])
+		}
+		fmt.Fprintf(tw, "%dns\t\n", lat.Max)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"BST/trees"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type opKind int
+
+const (
+	opFind opKind = iota
+	opInsert
+	opRemove
+	opCount
+)
+
+var opNames = [opCount]string{"find", "insert", "remove"}
+
+type result struct {
+	elapsed   time.Duration
+	latencies [opCount]histogram
+}
+
+func (r *result) ops() (total int64) {
+	for i := range r.latencies {
+		total += r.latencies[i].total
+	}
+	return
+}
+
+// keyGenerator returns the next key of a goroutine.
+type keyGenerator func() int
+
+func newKeyGenerator(cfg config, r *rand.Rand, goroutine int) keyGenerator {
+	switch cfg.distribution {
+	case "zipf":
+		// Rank 0 is the most popular key.
+		zipf := rand.NewZipf(r, cfg.zipfS, 1, uint64(cfg.keys-1))
+		return func() int {
+			return int(zipf.Uint64())
+		}
+	case "sequential":
+		// Goroutines interleave, so together they sweep the key range in order.
+		next := goroutine
+		return func() int {
+			key := next % cfg.keys
+			next += cfg.goroutines
+			return key
+		}
+	default:
+		return func() int {
+			return r.Intn(cfg.keys)
+		}
+	}
+}
+
+// prefill inserts a random prefill share of the key range, in random order
+// so that the unbalanced trees are not degenerate.
+func prefill(tree trees.Tree[int, int], cfg config) {
+	keys := rand.New(rand.NewSource(cfg.seed)).Perm(cfg.keys)
+	for _, key := range keys[:int(cfg.prefill*float64(cfg.keys))] {
+		tree.Insert(key, key)
+	}
+}
+
+func run(tree trees.Tree[int, int], cfg config) *result {
+	prefill(tree, cfg)
+
+	var stop atomic.Bool
+	perGoroutine := make([]result, cfg.goroutines)
+	wg := sync.WaitGroup{}
+	wg.Add(cfg.goroutines)
+
+	start := time.Now()
+	for g := 0; g < cfg.goroutines; g++ {
+		go func(g int) {
+			defer wg.Done()
+			r := rand.New(rand.NewSource(cfg.seed + int64(g) + 1))
+			nextKey := newKeyGenerator(cfg, r, g)
+			res := &perGoroutine[g]
+
+			// With -ops, the first goroutines take the remainder.
+			limit := int64(-1)
+			if cfg.ops > 0 {
+				limit = cfg.ops / int64(cfg.goroutines)
+				if int64(g) < cfg.ops%int64(cfg.goroutines) {
+					limit++
+				}
+			}
+
+			for i := int64(0); i != limit && !stop.Load(); i++ {
+				key := nextKey()
+				kind := opFind
+				switch p := r.Intn(100); {
+				case p < cfg.insPercent:
+					kind = opInsert
+				case p < cfg.insPercent+cfg.remPercent:
+					kind = opRemove
+				}
+
+				opStart := time.Now()
+				switch kind {
+				case opFind:
+					tree.Find(key)
+				case opInsert:
+					tree.Insert(key, key)
+				case opRemove:
+					tree.Remove(key)
+				}
+				res.latencies[kind].record(int64(time.Since(opStart)))
+			}
+		}(g)
+	}
+
+	if cfg.ops == 0 {
+		time.AfterFunc(cfg.duration, func() { stop.Store(true) })
+	}
+	wg.Wait()
+
+	total := &result{elapsed: time.Since(start)}
+	for g := range perGoroutine {
+		for kind := range total.latencies {
+			total.latencies[kind].merge(&perGoroutine[g].latencies[kind])
+		}
+	}
+	return total
+}
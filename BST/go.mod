@@ -1,3 +1,7 @@
 module BST
 
-go 1.21.5
+go 1.23
+
+require lincheck v0.0.0
+
+replace lincheck => ../lincheck
@@ -0,0 +1,180 @@
+package linearizability
+
+import (
+	"BST/trees"
+	"cmp"
+	"lincheck"
+)
+
+type MapOp int
+
+const (
+	MapFind MapOp = iota
+	MapInsert
+	MapRemove
+	MapLoadOrStore
+	MapCompareAndSwap
+	MapCompute
+	MapLoadAndDelete
+)
+
+// MapInput is a call on a key. Old is the expected value of CompareAndSwap,
+// whose new value is Value, and Compute is the function passed to Compute,
+// which must be deterministic for the model to replay it.
+type MapInput[T any, K cmp.Ordered] struct {
+	Op      MapOp
+	Key     K
+	Value   T
+	Old     T
+	Compute func(old T, loaded bool) (T, bool)
+}
+
+// MapOutput is the result of a call: the value and the flag of Find,
+// LoadOrStore, Compute and LoadAndDelete, or swapped of CompareAndSwap in
+// Exist. Insert and Remove have none, and Value is zero if the key is absent.
+type MapOutput[T any] struct {
+	Value T
+	Exist bool
+}
+
+// MapModel is the sequential specification of a map. Operations on different
+// keys are independent, so the history is partitioned by key and the state
+// is the value of a single key.
+func MapModel[T comparable, K cmp.Ordered]() lincheck.Model[MapOutput[T], MapInput[T, K], MapOutput[T]] {
+	return lincheck.Model[MapOutput[T], MapInput[T, K], MapOutput[T]]{
+		Partition: func(history []lincheck.Operation[MapInput[T, K], MapOutput[T]]) [][]lincheck.Operation[MapInput[T, K], MapOutput[T]] {
+			byKey := make(map[K][]lincheck.Operation[MapInput[T, K], MapOutput[T]])
+			for _, op := range history {
+				byKey[op.Input.Key] = append(byKey[op.Input.Key], op)
+			}
+			partitions := make([][]lincheck.Operation[MapInput[T, K], MapOutput[T]], 0, len(byKey))
+			for _, partition := range byKey {
+				partitions = append(partitions, partition)
+			}
+			return partitions
+		},
+		Init: func() MapOutput[T] {
+			return MapOutput[T]{}
+		},
+		Step: func(state MapOutput[T], input MapInput[T, K], output MapOutput[T]) (bool, MapOutput[T]) {
+			switch input.Op {
+			case MapInsert:
+				return true, MapOutput[T]{Value: input.Value, Exist: true}
+			case MapRemove:
+				return true, MapOutput[T]{}
+			case MapLoadOrStore:
+				if state.Exist {
+					return output == state, state
+				}
+				return output == MapOutput[T]{Value: input.Value}, MapOutput[T]{Value: input.Value, Exist: true}
+			case MapCompareAndSwap:
+				swapped := state.Exist && state.Value == input.Old
+				if !swapped {
+					return !output.Exist, state
+				}
+				return output.Exist, MapOutput[T]{Value: input.Value, Exist: true}
+			case MapCompute:
+				newState := MapOutput[T]{}
+				if value, keep := input.Compute(state.Value, state.Exist); keep {
+					newState = MapOutput[T]{Value: value, Exist: true}
+				}
+				return output == newState, newState
+			case MapLoadAndDelete:
+				return output == state, MapOutput[T]{}
+			default:
+				return output.Exist == state.Exist && (!state.Exist || output.Value == state.Value), state
+			}
+		},
+		Equal: func(a, b MapOutput[T]) bool {
+			return a == b
+		},
+	}
+}
+
+// TreeRecorder records the operations of goroutines sharing a tree.
+type TreeRecorder[T any, K cmp.Ordered] struct {
+	lincheck.Recorder[MapInput[T, K], MapOutput[T]]
+	tree trees.Tree[T, K]
+}
+
+func NewTreeRecorder[T any, K cmp.Ordered](tree trees.Tree[T, K]) *TreeRecorder[T, K] {
+	return &TreeRecorder[T, K]{tree: tree}
+}
+
+// RecordedTree is the view of the tree for one goroutine. Find, Insert and
+// Remove are recorded, the other methods are passed through.
+type RecordedTree[T any, K cmp.Ordered] struct {
+	trees.Tree[T, K]
+	client *lincheck.Client[MapInput[T, K], MapOutput[T]]
+}
+
+// Client returns the view of the tree for a new goroutine.
+func (r *TreeRecorder[T, K]) Client() *RecordedTree[T, K] {
+	return &RecordedTree[T, K]{Tree: r.tree, client: r.NewClient()}
+}
+
+func (t *RecordedTree[T, K]) Find(key K) (T, bool) {
+	output := t.client.Do(MapInput[T, K]{Op: MapFind, Key: key}, func() MapOutput[T] {
+		value, exist := t.Tree.Find(key)
+		return MapOutput[T]{Value: value, Exist: exist}
+	})
+	return output.Value, output.Exist
+}
+
+func (t *RecordedTree[T, K]) Insert(key K, value T) {
+	t.client.Do(MapInput[T, K]{Op: MapInsert, Key: key, Value: value}, func() MapOutput[T] {
+		t.Tree.Insert(key, value)
+		return MapOutput[T]{}
+	})
+}
+
+func (t *RecordedTree[T, K]) Remove(key K) {
+	t.client.Do(MapInput[T, K]{Op: MapRemove, Key: key}, func() MapOutput[T] {
+		t.Tree.Remove(key)
+		return MapOutput[T]{}
+	})
+}
+
+// RecordedAtomicTree is the view of an AtomicTree for one goroutine, its
+// read-modify-write operations are recorded as well.
+type RecordedAtomicTree[T any, K cmp.Ordered] struct {
+	*RecordedTree[T, K]
+	tree trees.AtomicTree[T, K]
+}
+
+// AtomicClient returns the view of the tree for a new goroutine. It panics if
+// the tree is not an AtomicTree.
+func (r *TreeRecorder[T, K]) AtomicClient() *RecordedAtomicTree[T, K] {
+	return &RecordedAtomicTree[T, K]{RecordedTree: r.Client(), tree: r.tree.(trees.AtomicTree[T, K])}
+}
+
+func (t *RecordedAtomicTree[T, K]) LoadOrStore(key K, value T) (T, bool) {
+	output := t.client.Do(MapInput[T, K]{Op: MapLoadOrStore, Key: key, Value: value}, func() MapOutput[T] {
+		actual, loaded := t.tree.LoadOrStore(key, value)
+		return MapOutput[T]{Value: actual, Exist: loaded}
+	})
+	return output.Value, output.Exist
+}
+
+func (t *RecordedAtomicTree[T, K]) CompareAndSwap(key K, old, new T) bool {
+	output := t.client.Do(MapInput[T, K]{Op: MapCompareAndSwap, Key: key, Value: new, Old: old}, func() MapOutput[T] {
+		return MapOutput[T]{Exist: t.tree.CompareAndSwap(key, old, new)}
+	})
+	return output.Exist
+}
+
+func (t *RecordedAtomicTree[T, K]) Compute(key K, f func(old T, loaded bool) (T, bool)) (T, bool) {
+	output := t.client.Do(MapInput[T, K]{Op: MapCompute, Key: key, Compute: f}, func() MapOutput[T] {
+		actual, present := t.tree.Compute(key, f)
+		return MapOutput[T]{Value: actual, Exist: present}
+	})
+	return output.Value, output.Exist
+}
+
+func (t *RecordedAtomicTree[T, K]) LoadAndDelete(key K) (T, bool) {
+	output := t.client.Do(MapInput[T, K]{Op: MapLoadAndDelete, Key: key}, func() MapOutput[T] {
+		value, loaded := t.tree.LoadAndDelete(key)
+		return MapOutput[T]{Value: value, Exist: loaded}
+	})
+	return output.Value, output.Exist
+}
@@ -0,0 +1,52 @@
+package locks
+
+import (
+	"math/rand"
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	DefaultMinDelay = time.Microsecond
+	DefaultMaxDelay = 100 * time.Microsecond
+)
+
+// BackoffLock is the test-and-test-and-set lock with exponential backoff:
+// after losing the race for a released lock the goroutine sleeps for a random
+// time below a limit, which doubles on every failure up to maxDelay.
+type BackoffLock struct {
+	state    atomic.Bool
+	minDelay time.Duration
+	maxDelay time.Duration
+}
+
+// NewBackoffLock returns a lock backing off between minDelay and maxDelay.
+// Non-positive values are replaced by DefaultMinDelay and DefaultMaxDelay.
+func NewBackoffLock(minDelay, maxDelay time.Duration) *BackoffLock {
+	if minDelay <= 0 {
+		minDelay = DefaultMinDelay
+	}
+	if maxDelay <= 0 {
+		maxDelay = DefaultMaxDelay
+	}
+	return &BackoffLock{minDelay: minDelay, maxDelay: max(minDelay, maxDelay)}
+}
+
+func (l *BackoffLock) Lock() {
+	limit := l.minDelay
+	for {
+		for l.state.Load() {
+			runtime.Gosched()
+		}
+		if !l.state.Swap(true) {
+			return
+		}
+		time.Sleep(time.Duration(rand.Int63n(int64(limit)) + 1))
+		limit = min(2*limit, l.maxDelay)
+	}
+}
+
+func (l *BackoffLock) Unlock() {
+	l.state.Store(false)
+}
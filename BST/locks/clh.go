@@ -0,0 +1,42 @@
+package locks
+
+import (
+	"runtime"
+	"sync/atomic"
+)
+
+// CLHLock is the queue lock of Craig, Landin and Hagersten. Waiters form an
+// implicit queue: each one appends its node at the tail and spins on the node
+// of its predecessor, so every waiter spins on a different location and the
+// lock is granted in FIFO order.
+//
+// Go has no goroutine-local storage, so the node of the holder is kept in the
+// lock itself; it is only accessed by the goroutine that holds the lock.
+type CLHLock struct {
+	tail  atomic.Pointer[clhNode]
+	owner *clhNode
+}
+
+type clhNode struct {
+	locked atomic.Bool
+}
+
+func NewCLHLock() *CLHLock {
+	return &CLHLock{}
+}
+
+func (l *CLHLock) Lock() {
+	node := &clhNode{}
+	node.locked.Store(true)
+	// A nil predecessor stands for the released initial node.
+	if pred := l.tail.Swap(node); pred != nil {
+		for pred.locked.Load() {
+			runtime.Gosched()
+		}
+	}
+	l.owner = node
+}
+
+func (l *CLHLock) Unlock() {
+	l.owner.locked.Store(false)
+}
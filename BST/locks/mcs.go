@@ -0,0 +1,51 @@
+package locks
+
+import (
+	"runtime"
+	"sync/atomic"
+)
+
+// MCSLock is the queue lock of Mellor-Crummey and Scott. Unlike CLHLock the
+// queue is explicit: a waiter links its node behind its predecessor and spins
+// on its own node, which the predecessor releases on Unlock.
+//
+// As in CLHLock, the node of the holder is kept in the lock itself.
+type MCSLock struct {
+	tail  atomic.Pointer[mcsNode]
+	owner *mcsNode
+}
+
+type mcsNode struct {
+	locked atomic.Bool
+	next   atomic.Pointer[mcsNode]
+}
+
+func NewMCSLock() *MCSLock {
+	return &MCSLock{}
+}
+
+func (l *MCSLock) Lock() {
+	node := &mcsNode{}
+	if pred := l.tail.Swap(node); pred != nil {
+		node.locked.Store(true)
+		pred.next.Store(node)
+		for node.locked.Load() {
+			runtime.Gosched()
+		}
+	}
+	l.owner = node
+}
+
+func (l *MCSLock) Unlock() {
+	node := l.owner
+	if node.next.Load() == nil {
+		if l.tail.CompareAndSwap(node, nil) {
+			return
+		}
+		// A successor has swapped the tail but not linked itself yet.
+		for node.next.Load() == nil {
+			runtime.Gosched()
+		}
+	}
+	node.next.Load().locked.Store(false)
+}
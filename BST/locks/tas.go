@@ -0,0 +1,30 @@
+package locks
+
+import (
+	"runtime"
+	"sync/atomic"
+)
+
+// TASLock is the test-and-set spin lock: every attempt to acquire it is an
+// atomic swap of the shared flag, so waiters keep invalidating its cache line.
+//
+// All spin locks in this package yield the processor with runtime.Gosched
+// while waiting, otherwise a waiter could hold up the goroutine that owns the
+// lock when GOMAXPROCS is small.
+type TASLock struct {
+	state atomic.Bool
+}
+
+func NewTASLock() *TASLock {
+	return &TASLock{}
+}
+
+func (l *TASLock) Lock() {
+	for l.state.Swap(true) {
+		runtime.Gosched()
+	}
+}
+
+func (l *TASLock) Unlock() {
+	l.state.Store(false)
+}
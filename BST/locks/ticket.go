@@ -0,0 +1,28 @@
+package locks
+
+import (
+	"runtime"
+	"sync/atomic"
+)
+
+// TicketLock is a first-come-first-served spin lock: Lock takes the next
+// ticket and waits until it is served, Unlock serves the next ticket.
+type TicketLock struct {
+	next    atomic.Uint64
+	serving atomic.Uint64
+}
+
+func NewTicketLock() *TicketLock {
+	return &TicketLock{}
+}
+
+func (l *TicketLock) Lock() {
+	ticket := l.next.Add(1) - 1
+	for l.serving.Load() != ticket {
+		runtime.Gosched()
+	}
+}
+
+func (l *TicketLock) Unlock() {
+	l.serving.Add(1)
+}
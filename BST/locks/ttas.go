@@ -0,0 +1,31 @@
+package locks
+
+import (
+	"runtime"
+	"sync/atomic"
+)
+
+// TTASLock is the test-and-test-and-set spin lock: waiters spin on loads of
+// the flag, which hit their local cache, and only swap it once it is released.
+type TTASLock struct {
+	state atomic.Bool
+}
+
+func NewTTASLock() *TTASLock {
+	return &TTASLock{}
+}
+
+func (l *TTASLock) Lock() {
+	for {
+		for l.state.Load() {
+			runtime.Gosched()
+		}
+		if !l.state.Swap(true) {
+			return
+		}
+	}
+}
+
+func (l *TTASLock) Unlock() {
+	l.state.Store(false)
+}
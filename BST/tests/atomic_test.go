@@ -0,0 +1,147 @@
+package tests
+
+import (
+	"BST/trees"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func isAtomic(myTree trees.Tree[int, int]) bool {
+	_, ok := myTree.(trees.AtomicTree[int, int])
+	return ok
+}
+
+func TestConditionalOperations(t *testing.T) {
+	for _, testStruct := range filterTrees(isAtomic) {
+		myTree := testStruct.currTree.(trees.AtomicTree[int, int])
+
+		if actual, loaded := myTree.LoadOrStore(1, 10); loaded || actual != 10 {
+			t.Errorf("LoadOrStore in %s tree expected (10, false), but get (%d, %t)", testStruct.typeSync, actual, loaded)
+		}
+		if actual, loaded := myTree.LoadOrStore(1, 20); !loaded || actual != 10 {
+			t.Errorf("LoadOrStore in %s tree expected (10, true), but get (%d, %t)", testStruct.typeSync, actual, loaded)
+		}
+
+		if myTree.CompareAndSwap(1, 20, 30) {
+			t.Errorf("CompareAndSwap in %s tree swapped a different value", testStruct.typeSync)
+		}
+		if myTree.CompareAndSwap(2, 0, 30) {
+			t.Errorf("CompareAndSwap in %s tree swapped a missing key", testStruct.typeSync)
+		}
+		if !myTree.CompareAndSwap(1, 10, 30) {
+			t.Errorf("CompareAndSwap in %s tree did not swap an equal value", testStruct.typeSync)
+		}
+		if value, _ := myTree.Find(1); value != 30 {
+			t.Errorf("Expected 30 in %s tree after CompareAndSwap, but get %d", testStruct.typeSync, value)
+		}
+
+		increment := func(old int, loaded bool) (int, bool) {
+			return old + 1, true
+		}
+		if actual, present := myTree.Compute(1, increment); !present || actual != 31 {
+			t.Errorf("Compute in %s tree expected (31, true), but get (%d, %t)", testStruct.typeSync, actual, present)
+		}
+		if actual, present := myTree.Compute(2, increment); !present || actual != 1 {
+			t.Errorf("Compute in %s tree expected (1, true), but get (%d, %t)", testStruct.typeSync, actual, present)
+		}
+		deleteAll := func(old int, loaded bool) (int, bool) {
+			return 0, false
+		}
+		if _, present := myTree.Compute(2, deleteAll); present {
+			t.Errorf("Compute in %s tree did not remove the key", testStruct.typeSync)
+		}
+		if _, present := myTree.Compute(3, deleteAll); present {
+			t.Errorf("Compute in %s tree created a removed key", testStruct.typeSync)
+		}
+		if _, exist := myTree.Find(2); exist {
+			t.Errorf("Key 2 still exists in %s tree after Compute", testStruct.typeSync)
+		}
+
+		if value, loaded := myTree.LoadAndDelete(1); !loaded || value != 31 {
+			t.Errorf("LoadAndDelete in %s tree expected (31, true), but get (%d, %t)", testStruct.typeSync, value, loaded)
+		}
+		if _, loaded := myTree.LoadAndDelete(1); loaded {
+			t.Errorf("LoadAndDelete in %s tree loaded a removed key", testStruct.typeSync)
+		}
+		if sz := myTree.Len(); sz != 0 || !myTree.IsValid() {
+			t.Errorf("Expected valid empty %s tree, but get size %d", testStruct.typeSync, sz)
+		}
+	}
+}
+
+func TestConditionalOperationsGoroutines(t *testing.T) {
+	const goroutineCount = 16
+	const opsCount = 1_000
+	const keysCount = 8
+
+	for _, testStruct := range filterTrees(isAtomic) {
+		myTree := testStruct.currTree.(trees.AtomicTree[int, int])
+
+		// Shared structure so that increments contend with inserts of other keys.
+		for _, key := range shuffledKeys(100) {
+			myTree.Insert(key*10+keysCount, 0)
+		}
+
+		var stored atomic.Int64
+		wg := sync.WaitGroup{}
+		wg.Add(goroutineCount)
+		for g := 0; g < goroutineCount; g++ {
+			go func(g int) {
+				defer wg.Done()
+				for i := 0; i < opsCount; i++ {
+					key := i % keysCount
+					if _, loaded := myTree.LoadOrStore(key, 0); !loaded {
+						stored.Add(1)
+					}
+					if g%2 == 0 {
+						myTree.Compute(key, func(old int, loaded bool) (int, bool) {
+							return old + 1, true
+						})
+						continue
+					}
+					for {
+						old, _ := myTree.Find(key)
+						if myTree.CompareAndSwap(key, old, old+1) {
+							break
+						}
+					}
+				}
+			}(g)
+		}
+		wg.Wait()
+
+		if stored.Load() != keysCount {
+			t.Errorf("LoadOrStore in %s tree stored %d times, expected %d", testStruct.typeSync, stored.Load(), keysCount)
+		}
+		total := 0
+		for key := 0; key < keysCount; key++ {
+			value, _ := myTree.Find(key)
+			total += value
+		}
+		if total != goroutineCount*opsCount {
+			t.Errorf("Lost updates in %s tree: expected %d, but get %d", testStruct.typeSync, goroutineCount*opsCount, total)
+		}
+
+		var deleted atomic.Int64
+		wg.Add(goroutineCount)
+		for g := 0; g < goroutineCount; g++ {
+			go func() {
+				defer wg.Done()
+				for key := range myTree.All() {
+					if _, loaded := myTree.LoadAndDelete(key); loaded {
+						deleted.Add(1)
+					}
+				}
+			}()
+		}
+		wg.Wait()
+
+		if deleted.Load() != keysCount+100 {
+			t.Errorf("LoadAndDelete in %s tree deleted %d keys, expected %d", testStruct.typeSync, deleted.Load(), keysCount+100)
+		}
+		if sz := myTree.Len(); sz != 0 || !myTree.IsValid() {
+			t.Errorf("Expected valid empty %s tree, but get size %d", testStruct.typeSync, sz)
+		}
+	}
+}
@@ -2,13 +2,64 @@ package tests
 
 import (
 	"BST/trees"
+	"runtime"
+	"sync"
+	"sync/atomic"
 	"testing"
 )
 
+// namedTree is a tree under test. Tests that need more than trees.Tree pick
+// the trees by a type assertion on currTree, see filterTrees.
+type namedTree struct {
+	currTree trees.Tree[int, int]
+	typeSync string
+}
+
+// newTestTrees returns a new instance of every tree, the ones with locks get
+// opts.
+func newTestTrees(opts ...trees.Option) []namedTree {
+	return []namedTree{
+		{trees.NewGrainedSyncTree[int, int](opts...), "simple"},
+		{trees.NewFineGrainedSyncTree[int, int](opts...), "fine grained"},
+		{trees.NewRWGrainedSyncTree[int, int](opts...), "rw simple"},
+		{trees.NewRWFineGrainedSyncTree[int, int](opts...), "rw fine grained"},
+		{trees.NewOptimisticSyncTree[int, int](opts...), "optimistic"},
+		{trees.NewLockFreeTree[int, int](), "lock-free"},
+		{trees.NewAVLTree[int, int](), "avl"},
+		{trees.NewSkipList[int, int](), "skip list"},
+	}
+}
+
+// filterTrees returns the trees of newTestTrees for which keep is true.
+func filterTrees(keep func(myTree trees.Tree[int, int]) bool, opts ...trees.Option) []namedTree {
+	var res []namedTree
+	for _, testStruct := range newTestTrees(opts...) {
+		if keep(testStruct.currTree) {
+			res = append(res, testStruct)
+		}
+	}
+	return res
+}
+
+// shuffledKeys returns 0..n-1 in an order that keeps the trees reasonably
+// balanced without depending on math/rand.
+func shuffledKeys(n int) []int {
+	keys := make([]int, n)
+	for i := range keys {
+		keys[i] = (i * 7919) % n
+	}
+	return keys
+}
+
 func TestInsert(t *testing.T) {
 	grTree := trees.NewGrainedSyncTree[int, int]()
 	fnGrTree := trees.NewFineGrainedSyncTree[int, int]()
+	rwGrTree := trees.NewRWGrainedSyncTree[int, int]()
+	rwFnGrTree := trees.NewRWFineGrainedSyncTree[int, int]()
 	optTree := trees.NewOptimisticSyncTree[int, int]()
+	lockFreeTree := trees.NewLockFreeTree[int, int]()
+	avlTree := trees.NewAVLTree[int, int]()
+	skipList := trees.NewSkipList[int, int]()
 
 	var tests = []struct {
 		currTree trees.Tree[int, int]
@@ -16,7 +67,12 @@ func TestInsert(t *testing.T) {
 	}{
 		{grTree, "simple"},
 		{fnGrTree, "fine grained"},
+		{rwGrTree, "rw simple"},
+		{rwFnGrTree, "rw fine grained"},
 		{optTree, "optimistic"},
+		{lockFreeTree, "lock-free"},
+		{avlTree, "avl"},
+		{skipList, "skip list"},
 	}
 
 	for _, testStruct := range tests {
@@ -38,7 +94,12 @@ func TestInsert(t *testing.T) {
 func TestRemove(t *testing.T) {
 	grTree := trees.NewGrainedSyncTree[int, int]()
 	fnGrTree := trees.NewFineGrainedSyncTree[int, int]()
+	rwGrTree := trees.NewRWGrainedSyncTree[int, int]()
+	rwFnGrTree := trees.NewRWFineGrainedSyncTree[int, int]()
 	optTree := trees.NewOptimisticSyncTree[int, int]()
+	lockFreeTree := trees.NewLockFreeTree[int, int]()
+	avlTree := trees.NewAVLTree[int, int]()
+	skipList := trees.NewSkipList[int, int]()
 
 	var tests = []struct {
 		currTree trees.Tree[int, int]
@@ -46,7 +107,12 @@ func TestRemove(t *testing.T) {
 	}{
 		{grTree, "simple"},
 		{fnGrTree, "fine grained"},
+		{rwGrTree, "rw simple"},
+		{rwFnGrTree, "rw fine grained"},
 		{optTree, "optimistic"},
+		{lockFreeTree, "lock-free"},
+		{avlTree, "avl"},
+		{skipList, "skip list"},
 	}
 
 	for _, testStruct := range tests {
@@ -75,3 +141,256 @@ func TestRemove(t *testing.T) {
 		}
 	}
 }
+
+func TestNavigation(t *testing.T) {
+	for _, testStruct := range newTestTrees() {
+		myTree := testStruct.currTree
+		if _, _, exist := myTree.Min(); exist {
+			t.Errorf("Min of empty %s tree should not exist", testStruct.typeSync)
+		}
+		if _, _, exist := myTree.Max(); exist {
+			t.Errorf("Max of empty %s tree should not exist", testStruct.typeSync)
+		}
+		if _, _, exist := myTree.Floor(0); exist {
+			t.Errorf("Floor in empty %s tree should not exist", testStruct.typeSync)
+		}
+		if _, _, exist := myTree.Ceiling(0); exist {
+			t.Errorf("Ceiling in empty %s tree should not exist", testStruct.typeSync)
+		}
+
+		// Keys 10, 20, ..., 990 in a balanced-ish order.
+		for _, key := range shuffledKeys(100) {
+			if key != 0 {
+				myTree.Insert(key*10, key)
+			}
+		}
+
+		if key, value, exist := myTree.Min(); !exist || key != 10 || value != 1 {
+			t.Errorf("Expected min (10, 1) in %s tree, but get (%d, %d, %t)", testStruct.typeSync, key, value, exist)
+		}
+		if key, value, exist := myTree.Max(); !exist || key != 990 || value != 99 {
+			t.Errorf("Expected max (990, 99) in %s tree, but get (%d, %d, %t)", testStruct.typeSync, key, value, exist)
+		}
+
+		var tests = []struct {
+			probe                int
+			floor, ceiling       int
+			hasFloor, hasCeiling bool
+		}{
+			{5, 0, 10, false, true},
+			{10, 10, 10, true, true},
+			{15, 10, 20, true, true},
+			{500, 500, 500, true, true},
+			{989, 980, 990, true, true},
+			{995, 990, 0, true, false},
+		}
+		for _, tt := range tests {
+			key, value, exist := myTree.Floor(tt.probe)
+			if exist != tt.hasFloor || (exist && (key != tt.floor || value != key/10)) {
+				t.Errorf("Floor(%d) in %s tree: expected (%d, %t), but get (%d, %d, %t)", tt.probe, testStruct.typeSync, tt.floor, tt.hasFloor, key, value, exist)
+			}
+			key, value, exist = myTree.Ceiling(tt.probe)
+			if exist != tt.hasCeiling || (exist && (key != tt.ceiling || value != key/10)) {
+				t.Errorf("Ceiling(%d) in %s tree: expected (%d, %t), but get (%d, %d, %t)", tt.probe, testStruct.typeSync, tt.ceiling, tt.hasCeiling, key, value, exist)
+			}
+		}
+
+		if sz := myTree.Len(); sz != 99 {
+			t.Errorf("Len of %s tree expected %d, but get %d", testStruct.typeSync, 99, sz)
+		}
+		myTree.Insert(500, 50)
+		if sz := myTree.Len(); sz != 99 {
+			t.Errorf("Len of %s tree changed after overwrite: %d", testStruct.typeSync, sz)
+		}
+		myTree.Remove(500)
+		myTree.Remove(501)
+		if sz := myTree.Len(); sz != 98 {
+			t.Errorf("Len of %s tree expected %d, but get %d", testStruct.typeSync, 98, sz)
+		}
+		if key, _, _ := myTree.Floor(500); key != 490 {
+			t.Errorf("Floor(500) in %s tree expected 490 after remove, but get %d", testStruct.typeSync, key)
+		}
+		if key, _, _ := myTree.Ceiling(500); key != 510 {
+			t.Errorf("Ceiling(500) in %s tree expected 510 after remove, but get %d", testStruct.typeSync, key)
+		}
+	}
+}
+
+func TestNavigationGoroutines(t *testing.T) {
+	const goroutineCount = 8
+	const keysCount = 1_000
+
+	for _, testStruct := range newTestTrees() {
+		myTree := testStruct.currTree
+		wg := sync.WaitGroup{}
+		wg.Add(goroutineCount)
+		for g := 0; g < goroutineCount; g++ {
+			go func(g int) {
+				defer wg.Done()
+				for _, key := range shuffledKeys(keysCount) {
+					if key%goroutineCount == g {
+						myTree.Insert(key, key)
+					}
+				}
+			}(g)
+		}
+		wg.Wait()
+		if sz := myTree.Len(); sz != keysCount {
+			t.Errorf("Len of %s tree expected %d, but get %d", testStruct.typeSync, keysCount, sz)
+		}
+
+		// Multiples of 10 are stable, the rest is removed while navigating.
+		wg.Add(goroutineCount)
+		for g := 0; g < goroutineCount; g++ {
+			go func(g int) {
+				defer wg.Done()
+				for _, key := range shuffledKeys(keysCount) {
+					if key%10 != 0 && key%goroutineCount == g {
+						myTree.Remove(key)
+					}
+				}
+			}(g)
+		}
+		for probe := 0; probe < keysCount; probe++ {
+			if key, value, exist := myTree.Floor(probe); !exist || key > probe || key < probe-probe%10 || value != key {
+				t.Errorf("Floor(%d) in %s tree returned (%d, %d, %t)", probe, testStruct.typeSync, key, value, exist)
+			}
+			if key, _, exist := myTree.Min(); !exist || key != 0 {
+				t.Errorf("Min of %s tree returned (%d, %t)", testStruct.typeSync, key, exist)
+			}
+		}
+		wg.Wait()
+
+		if sz := myTree.Len(); sz != keysCount/10 {
+			t.Errorf("Len of %s tree expected %d, but get %d", testStruct.typeSync, keysCount/10, sz)
+		}
+		if key, _, exist := myTree.Max(); !exist || key != keysCount-10 {
+			t.Errorf("Max of %s tree expected %d, but get (%d, %t)", testStruct.typeSync, keysCount-10, key, exist)
+		}
+	}
+}
+
+func TestAVLBalance(t *testing.T) {
+	tree := trees.NewAVLTree[int, int]()
+	elements := 1 << 12
+	for i := 0; i < elements; i++ {
+		tree.Insert(i, i)
+	}
+	// An AVL tree with n nodes is at most 1.44 * log2(n) high.
+	if h := tree.Height(); h > 18 {
+		t.Errorf("Sequential inserts of %d keys give height %d", elements, h)
+	}
+
+	wg := sync.WaitGroup{}
+	wg.Add(8)
+	for g := 0; g < 8; g++ {
+		go func(g int) {
+			defer wg.Done()
+			for i := g; i < elements; i += 8 {
+				if i%4 != 0 {
+					tree.Remove(i)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	if sz := tree.Len(); sz != elements/4 || !tree.IsValid() {
+		t.Errorf("Expected valid tree of %d keys, but get %d", elements/4, sz)
+	}
+	if h := tree.Height(); h > 16 {
+		t.Errorf("Concurrent removes left %d keys with height %d", elements/4, h)
+	}
+}
+
+// TestLockFreeRemoveGoroutines removes sibling leaves of the lock-free tree
+// concurrently, every key by several goroutines at once, while other keys are
+// inserted between them, so that removals flag and tag neighbouring edges and
+// help each other finish.
+func TestLockFreeRemoveGoroutines(t *testing.T) {
+	const goroutineCount = 8
+	const keysCount = 1 << 10
+
+	for round := 0; round < 10; round++ {
+		tree := trees.NewLockFreeTree[int, int]()
+		// Even keys are inserted in order, so that neighbours are siblings.
+		for key := 0; key < 2*keysCount; key += 2 {
+			tree.Insert(key, key)
+		}
+
+		wg := sync.WaitGroup{}
+		wg.Add(2 * goroutineCount)
+		for g := 0; g < goroutineCount; g++ {
+			go func() {
+				defer wg.Done()
+				for _, key := range shuffledKeys(keysCount) {
+					if key%4 != 0 {
+						tree.Remove(2 * key)
+					}
+				}
+			}()
+			go func(g int) {
+				defer wg.Done()
+				for key := 2*g + 1; key < 2*keysCount; key += 2 * goroutineCount {
+					tree.Insert(key, key)
+				}
+			}(g)
+		}
+		wg.Wait()
+
+		if sz := tree.Len(); sz != keysCount/4+keysCount || !tree.IsValid() {
+			t.Fatalf("Expected valid tree of %d keys, but get %d in round %d", keysCount/4+keysCount, sz, round)
+		}
+		for key := 0; key < 2*keysCount; key++ {
+			_, exist := tree.Find(key)
+			if expected := key%2 == 1 || key%8 == 0; exist != expected {
+				t.Fatalf("Find(%d) expected %t, but get %t in round %d", key, expected, exist, round)
+			}
+		}
+	}
+}
+
+// TestSkipListValidGoroutines calls IsValid while goroutines insert and
+// remove, so that it meets nodes linked on the bottom level only and nodes
+// that are removed from some levels but not from the others.
+func TestSkipListValidGoroutines(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(max(runtime.GOMAXPROCS(0), 4)))
+	const goroutineCount = 4
+	const keysCount = 1 << 8
+	const rounds = 50
+
+	list := trees.NewSkipList[int, int]()
+	var done atomic.Bool
+	wg := sync.WaitGroup{}
+	wg.Add(goroutineCount)
+	for g := 0; g < goroutineCount; g++ {
+		go func(g int) {
+			defer wg.Done()
+			for round := 0; round < rounds; round++ {
+				for _, key := range shuffledKeys(keysCount) {
+					if key%goroutineCount == g {
+						list.Insert(key, key)
+					}
+				}
+				for _, key := range shuffledKeys(keysCount) {
+					if key%goroutineCount == g {
+						list.Remove(key)
+					}
+				}
+			}
+		}(g)
+	}
+	go func() {
+		wg.Wait()
+		done.Store(true)
+	}()
+	for !done.Load() {
+		if !list.IsValid() {
+			t.Fatalf("IsValid of the skip list failed during concurrent updates")
+		}
+	}
+
+	if sz := list.Len(); sz != 0 || !list.IsValid() {
+		t.Errorf("Expected valid empty skip list, but get size %d", sz)
+	}
+}
@@ -0,0 +1,188 @@
+package tests
+
+import (
+	"BST/trees"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestAll(t *testing.T) {
+	for _, testStruct := range newTestTrees() {
+		myTree := testStruct.currTree
+		for range myTree.All() {
+			t.Errorf("Empty %s tree yielded a pair", testStruct.typeSync)
+		}
+
+		for _, key := range shuffledKeys(100) {
+			myTree.Insert(key, key*10)
+		}
+
+		expected := 0
+		for key, value := range myTree.All() {
+			if key != expected || value != key*10 {
+				t.Errorf("Expected (%d, %d) in %s tree, but get (%d, %d)", expected, expected*10, testStruct.typeSync, key, value)
+			}
+			expected++
+		}
+		if expected != 100 {
+			t.Errorf("Expected 100 pairs in %s tree, but get %d", testStruct.typeSync, expected)
+		}
+
+		count := 0
+		for range myTree.All() {
+			count++
+			if count == 10 {
+				break
+			}
+		}
+		if count != 10 {
+			t.Errorf("Break did not stop iteration of %s tree", testStruct.typeSync)
+		}
+	}
+}
+
+func TestRange(t *testing.T) {
+	for _, testStruct := range newTestTrees() {
+		myTree := testStruct.currTree
+		for _, key := range shuffledKeys(100) {
+			if key%2 == 0 {
+				myTree.Insert(key, key)
+			}
+		}
+
+		var tests = []struct {
+			lo, hi   int
+			expected []int
+		}{
+			{10, 20, []int{10, 12, 14, 16, 18}},
+			{11, 19, []int{12, 14, 16, 18}},
+			{-5, 3, []int{0, 2}},
+			{95, 200, []int{96, 98}},
+			{50, 50, nil},
+			{60, 40, nil},
+			{200, 300, nil},
+		}
+
+		for _, tt := range tests {
+			var got []int
+			for key := range myTree.Range(tt.lo, tt.hi) {
+				got = append(got, key)
+			}
+			if len(got) != len(tt.expected) {
+				t.Errorf("Range [%d, %d) of %s tree: expected %v, but get %v", tt.lo, tt.hi, testStruct.typeSync, tt.expected, got)
+				continue
+			}
+			for i := range got {
+				if got[i] != tt.expected[i] {
+					t.Errorf("Range [%d, %d) of %s tree: expected %v, but get %v", tt.lo, tt.hi, testStruct.typeSync, tt.expected, got)
+					break
+				}
+			}
+		}
+	}
+}
+
+// TestIterateWhileWriting checks the weakly consistent guarantee shared by all
+// trees: ascending order, no stale values and no missed stable keys.
+func TestIterateWhileWriting(t *testing.T) {
+	const keysCount = 1_000
+
+	for _, testStruct := range newTestTrees() {
+		myTree := testStruct.currTree
+		// Even keys are stable, odd keys are inserted and removed by writers.
+		for _, key := range shuffledKeys(keysCount) {
+			if key%2 == 0 {
+				myTree.Insert(key, key)
+			}
+		}
+
+		var stop atomic.Bool
+		wg := sync.WaitGroup{}
+		for w := 0; w < 4; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				for i := 0; !stop.Load(); i++ {
+					key := (i*8+w*2)%keysCount + 1
+					myTree.Insert(key, key)
+					myTree.Remove(key)
+				}
+			}(w)
+		}
+
+		for round := 0; round < 20; round++ {
+			prev, stable := -1, 0
+			for key, value := range myTree.All() {
+				if key <= prev {
+					t.Errorf("Keys of %s tree are not ascending: %d after %d", testStruct.typeSync, key, prev)
+				}
+				if value != key {
+					t.Errorf("Expected value %d for key %d in %s tree, but get %d", key, key, testStruct.typeSync, value)
+				}
+				if key%2 == 0 {
+					stable++
+				}
+				prev = key
+			}
+			if stable != keysCount/2 {
+				t.Errorf("Expected %d stable keys in %s tree, but get %d", keysCount/2, testStruct.typeSync, stable)
+			}
+
+			stable = 0
+			for key := range myTree.Range(100, 200) {
+				if key < 100 || key >= 200 {
+					t.Errorf("Key %d out of range [100, 200) in %s tree", key, testStruct.typeSync)
+				}
+				if key%2 == 0 {
+					stable++
+				}
+			}
+			if stable != 50 {
+				t.Errorf("Expected 50 stable keys in range of %s tree, but get %d", testStruct.typeSync, stable)
+			}
+		}
+
+		stop.Store(true)
+		wg.Wait()
+	}
+}
+
+// TestGrainedSnapshot checks that the coarse tree yields an atomic snapshot:
+// keys are inserted in a fixed order, so every snapshot must be its prefix.
+func TestGrainedSnapshot(t *testing.T) {
+	const keysCount = 10_000
+
+	tree := trees.NewGrainedSyncTree[int, int]()
+	order := shuffledKeys(keysCount)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, key := range order {
+			tree.Insert(key, key)
+		}
+	}()
+
+	for finished := false; !finished; {
+		select {
+		case <-done:
+			finished = true
+		default:
+		}
+
+		seen := make(map[int]bool)
+		for key := range tree.All() {
+			seen[key] = true
+		}
+		for i, key := range order {
+			if !seen[key] {
+				for _, rest := range order[i:] {
+					if seen[rest] {
+						t.Fatalf("Snapshot contains key %d inserted after missing key %d", rest, key)
+					}
+				}
+				break
+			}
+		}
+	}
+}
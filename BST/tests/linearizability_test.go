@@ -0,0 +1,197 @@
+package tests
+
+import (
+	"BST/linearizability"
+	"lincheck"
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+type mapOperation = lincheck.Operation[linearizability.MapInput[int, int], linearizability.MapOutput[int]]
+
+func mapOp(op linearizability.MapOp, key, value int, exist bool, call, ret int64) mapOperation {
+	return mapOperation{
+		Input:  linearizability.MapInput[int, int]{Op: op, Key: key, Value: value},
+		Output: linearizability.MapOutput[int]{Value: value, Exist: exist},
+		Call:   call,
+		Return: ret,
+	}
+}
+
+func casOp(key, old, new int, swapped bool, call, ret int64) mapOperation {
+	return mapOperation{
+		Input:  linearizability.MapInput[int, int]{Op: linearizability.MapCompareAndSwap, Key: key, Value: new, Old: old},
+		Output: linearizability.MapOutput[int]{Exist: swapped},
+		Call:   call,
+		Return: ret,
+	}
+}
+
+func increment(old int, loaded bool) (int, bool) {
+	return old + 1, true
+}
+
+func computeOp(key, value int, present bool, call, ret int64) mapOperation {
+	return mapOperation{
+		Input:  linearizability.MapInput[int, int]{Op: linearizability.MapCompute, Key: key, Compute: increment},
+		Output: linearizability.MapOutput[int]{Value: value, Exist: present},
+		Call:   call,
+		Return: ret,
+	}
+}
+
+func TestCheckMap(t *testing.T) {
+	model := linearizability.MapModel[int, int]()
+
+	var tests = []struct {
+		history      []mapOperation
+		linearizable bool
+		name         string
+	}{
+		{[]mapOperation{
+			mapOp(linearizability.MapInsert, 1, 10, false, 1, 2),
+			mapOp(linearizability.MapFind, 1, 10, true, 3, 4),
+		}, true, "find after insert"},
+		{[]mapOperation{
+			mapOp(linearizability.MapInsert, 1, 10, false, 1, 2),
+			mapOp(linearizability.MapFind, 1, 0, false, 3, 4),
+		}, false, "lost insert"},
+		{[]mapOperation{
+			mapOp(linearizability.MapInsert, 1, 10, false, 1, 4),
+			mapOp(linearizability.MapFind, 1, 0, false, 2, 3),
+		}, true, "find overlapping insert"},
+		{[]mapOperation{
+			mapOp(linearizability.MapInsert, 1, 10, false, 1, 6),
+			mapOp(linearizability.MapFind, 1, 10, true, 2, 3),
+			mapOp(linearizability.MapFind, 1, 0, false, 4, 5),
+		}, false, "insert observed and unobserved"},
+		{[]mapOperation{
+			mapOp(linearizability.MapInsert, 1, 10, false, 1, 2),
+			mapOp(linearizability.MapInsert, 2, 20, false, 3, 8),
+			mapOp(linearizability.MapRemove, 1, 0, false, 4, 7),
+			mapOp(linearizability.MapFind, 2, 20, true, 5, 6),
+			mapOp(linearizability.MapFind, 1, 10, true, 9, 10),
+		}, false, "find after remove"},
+		{[]mapOperation{
+			mapOp(linearizability.MapLoadOrStore, 1, 10, false, 1, 4),
+			mapOp(linearizability.MapLoadOrStore, 1, 10, true, 2, 3),
+		}, true, "load overlapping store"},
+		{[]mapOperation{
+			mapOp(linearizability.MapLoadOrStore, 1, 10, false, 1, 2),
+			mapOp(linearizability.MapLoadOrStore, 1, 20, false, 3, 4),
+		}, false, "store twice"},
+		{[]mapOperation{
+			mapOp(linearizability.MapInsert, 1, 10, false, 1, 2),
+			casOp(1, 10, 20, true, 3, 6),
+			casOp(1, 10, 30, true, 4, 5),
+		}, false, "swap twice"},
+		{[]mapOperation{
+			mapOp(linearizability.MapInsert, 1, 10, false, 1, 2),
+			casOp(1, 10, 20, true, 3, 6),
+			mapOp(linearizability.MapLoadAndDelete, 1, 20, true, 4, 5),
+		}, true, "delete overlapping swap"},
+		{[]mapOperation{
+			mapOp(linearizability.MapInsert, 1, 10, false, 1, 2),
+			mapOp(linearizability.MapLoadAndDelete, 1, 10, true, 3, 6),
+			mapOp(linearizability.MapLoadAndDelete, 1, 10, true, 4, 5),
+		}, false, "delete twice"},
+		{[]mapOperation{
+			computeOp(1, 1, true, 1, 4),
+			computeOp(1, 2, true, 2, 3),
+		}, true, "concurrent increments"},
+		{[]mapOperation{
+			computeOp(1, 1, true, 1, 4),
+			computeOp(1, 1, true, 2, 3),
+		}, false, "lost increment"},
+	}
+
+	for _, testStruct := range tests {
+		if res := lincheck.Check(model, testStruct.history); res != testStruct.linearizable {
+			t.Errorf("History %q expected linearizable = %t, but get %t", testStruct.name, testStruct.linearizable, res)
+		}
+	}
+}
+
+func TestLinearizability(t *testing.T) {
+	const goroutineCount = 4
+	const opsCount = 300
+	const keysCount = 4
+
+	for _, testStruct := range newTestTrees() {
+		recorder := linearizability.NewTreeRecorder(testStruct.currTree)
+
+		wg := sync.WaitGroup{}
+		wg.Add(goroutineCount)
+		for g := 0; g < goroutineCount; g++ {
+			myTree := recorder.Client()
+			go func(g int) {
+				defer wg.Done()
+				r := rand.New(rand.NewSource(int64(g)))
+				for i := 0; i < opsCount; i++ {
+					key := r.Intn(keysCount)
+					switch r.Intn(3) {
+					case 0:
+						myTree.Insert(key, g*opsCount+i)
+					case 1:
+						myTree.Remove(key)
+					default:
+						myTree.Find(key)
+					}
+				}
+			}(g)
+		}
+		wg.Wait()
+
+		if !lincheck.Check(linearizability.MapModel[int, int](), recorder.History()) {
+			t.Errorf("History of %s tree is not linearizable", testStruct.typeSync)
+		}
+	}
+}
+
+func TestAtomicLinearizability(t *testing.T) {
+	const goroutineCount = 4
+	const opsCount = 300
+	const keysCount = 4
+
+	// decrement removes the key once its value drops to zero.
+	decrement := func(old int, loaded bool) (int, bool) {
+		return old - 1, old > 1
+	}
+
+	for _, testStruct := range filterTrees(isAtomic) {
+		recorder := linearizability.NewTreeRecorder(testStruct.currTree)
+
+		wg := sync.WaitGroup{}
+		wg.Add(goroutineCount)
+		for g := 0; g < goroutineCount; g++ {
+			myTree := recorder.AtomicClient()
+			go func(g int) {
+				defer wg.Done()
+				r := rand.New(rand.NewSource(int64(g)))
+				for i := 0; i < opsCount; i++ {
+					key := r.Intn(keysCount)
+					switch r.Intn(6) {
+					case 0:
+						myTree.LoadOrStore(key, r.Intn(4))
+					case 1:
+						myTree.CompareAndSwap(key, r.Intn(4), r.Intn(4))
+					case 2:
+						myTree.Compute(key, increment)
+					case 3:
+						myTree.Compute(key, decrement)
+					case 4:
+						myTree.LoadAndDelete(key)
+					default:
+						myTree.Find(key)
+					}
+				}
+			}(g)
+		}
+		wg.Wait()
+
+		if !lincheck.Check(linearizability.MapModel[int, int](), recorder.History()) {
+			t.Errorf("History of atomic operations of %s tree is not linearizable", testStruct.typeSync)
+		}
+	}
+}
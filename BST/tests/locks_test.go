@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"BST/locks"
+	"BST/trees"
+	"sync"
+	"testing"
+	"time"
+)
+
+var lockFactories = []struct {
+	newLock  func() sync.Locker
+	typeLock string
+}{
+	{func() sync.Locker { return locks.NewTASLock() }, "TAS"},
+	{func() sync.Locker { return locks.NewTTASLock() }, "TTAS"},
+	{func() sync.Locker { return locks.NewBackoffLock(time.Microsecond, 50*time.Microsecond) }, "backoff"},
+	{func() sync.Locker { return locks.NewTicketLock() }, "ticket"},
+	{func() sync.Locker { return locks.NewCLHLock() }, "CLH"},
+	{func() sync.Locker { return locks.NewMCSLock() }, "MCS"},
+	{func() sync.Locker { return &sync.RWMutex{} }, "RWMutex"},
+}
+
+func TestMutualExclusion(t *testing.T) {
+	const goroutineCount = 8
+	const opsCount = 2_000
+
+	for _, testStruct := range lockFactories {
+		lock := testStruct.newLock()
+		counter := 0
+		inside := 0
+
+		wg := sync.WaitGroup{}
+		wg.Add(goroutineCount)
+		for g := 0; g < goroutineCount; g++ {
+			go func() {
+				defer wg.Done()
+				for i := 0; i < opsCount; i++ {
+					lock.Lock()
+					inside++
+					if inside != 1 {
+						t.Errorf("%d goroutines inside %s lock", inside, testStruct.typeLock)
+					}
+					counter++
+					inside--
+					lock.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+
+		if counter != goroutineCount*opsCount {
+			t.Errorf("Lost updates under %s lock: expected %d, but get %d", testStruct.typeLock, goroutineCount*opsCount, counter)
+		}
+	}
+}
+
+// takesLocks reports whether the tree makes its locks with WithLockFactory.
+func takesLocks(myTree trees.Tree[int, int]) bool {
+	switch myTree.(type) {
+	case *trees.GrainedSyncTree[int, int], *trees.FineGrainedSyncTree[int, int], *trees.OptimisticTree[int, int]:
+		return true
+	}
+	return false
+}
+
+func TestTreesWithLocks(t *testing.T) {
+	const goroutineCount = 8
+
+	for _, lockStruct := range lockFactories {
+		option := trees.WithLockFactory(lockStruct.newLock)
+		for _, testStruct := range filterTrees(takesLocks, option) {
+			myTree := testStruct.currTree
+			keys := shuffledKeys(1_000)
+
+			wg := sync.WaitGroup{}
+			wg.Add(goroutineCount)
+			for g := 0; g < goroutineCount; g++ {
+				go func(g int) {
+					defer wg.Done()
+					for i := g; i < len(keys); i += goroutineCount {
+						myTree.Insert(keys[i], keys[i])
+						if i%2 == 0 {
+							myTree.Remove(keys[i])
+						}
+					}
+				}(g)
+			}
+			wg.Wait()
+
+			if sz := myTree.Len(); sz != len(keys)/2 || !myTree.IsValid() {
+				t.Errorf("Expected valid %s tree with %s locks of size %d, but get %d", testStruct.typeSync, lockStruct.typeLock, len(keys)/2, sz)
+			}
+			for i, key := range keys {
+				if _, exist := myTree.Find(key); exist != (i%2 == 1) {
+					t.Errorf("Key %d in %s tree with %s locks: expected exist = %t", key, testStruct.typeSync, lockStruct.typeLock, i%2 == 1)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,855 @@
+package trees
+
+import (
+	"cmp"
+	"iter"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// AVLTree is the concurrent relaxed-balance AVL tree of Bronson, Casper, Chafi
+// and Olukotun. Readers never lock: they walk down optimistically and validate
+// every step with the version of the node they came from. A version changes
+// only when a rotation moves keys out of the node's subtree (the node shrinks)
+// or the node is unlinked, so growing subtrees do not invalidate searches.
+//
+// Writers lock the nodes they modify, always a parent before its child.
+// Removing a node with two children only clears its value and leaves a
+// routing node that is unlinked later, when it has at most one child.
+// Heights are repaired and rotations are done after the update, bottom-up,
+// so the tree may be briefly out of balance while operations are running.
+type AVLTree[T any, K cmp.Ordered] struct {
+	// rootHolder is a sentinel whose right child is the root.
+	rootHolder *AVLNode[T, K]
+	size       atomic.Int64
+}
+
+type AVLNode[T any, K cmp.Ordered] struct {
+	key K
+	// value is nil for routing nodes.
+	value   atomic.Pointer[T]
+	height  atomic.Int32
+	version atomic.Int64
+	parent  atomic.Pointer[AVLNode[T, K]]
+	left    atomic.Pointer[AVLNode[T, K]]
+	right   atomic.Pointer[AVLNode[T, K]]
+	mutex   *sync.Mutex
+}
+
+const (
+	unlinkedVersion  = 1
+	shrinkingVersion = 2
+
+	shrinkSpinCount  = 100
+	shrinkYieldCount = 10
+)
+
+// Results of nodeCondition besides a new height.
+const (
+	unlinkRequired    = -1
+	rebalanceRequired = -2
+	nothingRequired   = -3
+)
+
+func isShrinkingOrUnlinked(version int64) bool {
+	return version&(shrinkingVersion|unlinkedVersion) != 0
+}
+
+func isUnlinked(version int64) bool {
+	return version&unlinkedVersion != 0
+}
+
+func beginShrink(version int64) int64 {
+	return version | shrinkingVersion
+}
+
+// endShrink clears the shrinking bit and carries into the shrink counter.
+func endShrink(version int64) int64 {
+	return beginShrink(version) + shrinkingVersion
+}
+
+func NewAVLTree[T any, K cmp.Ordered]() *AVLTree[T, K] {
+	return &AVLTree[T, K]{rootHolder: newAVLNode[T, K](*new(K), nil, nil)}
+}
+
+func newAVLNode[T any, K cmp.Ordered](key K, value *T, parent *AVLNode[T, K]) *AVLNode[T, K] {
+	node := &AVLNode[T, K]{key: key, mutex: &sync.Mutex{}}
+	node.value.Store(value)
+	node.height.Store(1)
+	node.parent.Store(parent)
+	return node
+}
+
+func (n *AVLNode[T, K]) Lock() {
+	n.mutex.Lock()
+}
+
+func (n *AVLNode[T, K]) Unlock() {
+	n.mutex.Unlock()
+}
+
+func (n *AVLNode[T, K]) child(dir int) *AVLNode[T, K] {
+	if dir < 0 {
+		return n.left.Load()
+	}
+	return n.right.Load()
+}
+
+func (n *AVLNode[T, K]) setChild(dir int, child *AVLNode[T, K]) {
+	if dir < 0 {
+		n.left.Store(child)
+	} else {
+		n.right.Store(child)
+	}
+}
+
+// replaceChild points the edge of n that leads to old at repl.
+func (n *AVLNode[T, K]) replaceChild(old, repl *AVLNode[T, K]) {
+	if n.left.Load() == old {
+		n.left.Store(repl)
+	} else {
+		n.right.Store(repl)
+	}
+}
+
+func (n *AVLNode[T, K]) loadValue() (value T, exist bool) {
+	if ptr := n.value.Load(); ptr != nil {
+		return *ptr, true
+	}
+	return
+}
+
+func avlHeight[T any, K cmp.Ordered](n *AVLNode[T, K]) int32 {
+	if n == nil {
+		return 0
+	}
+	return n.height.Load()
+}
+
+// waitUntilShrinkCompleted waits for the rotation that set version to finish.
+// The rotating thread holds the node lock, so the last resort is to lock it.
+func (n *AVLNode[T, K]) waitUntilShrinkCompleted(version int64) {
+	if version&shrinkingVersion == 0 {
+		return
+	}
+	for i := 0; i < shrinkSpinCount; i++ {
+		if n.version.Load() != version {
+			return
+		}
+	}
+	for i := 0; i < shrinkYieldCount; i++ {
+		runtime.Gosched()
+		if n.version.Load() != version {
+			return
+		}
+	}
+	n.Lock()
+	n.Unlock()
+}
+
+func (t *AVLTree[T, K]) Find(key K) (value T, exist bool) {
+	for {
+		root := t.rootHolder.right.Load()
+		if root == nil {
+			return
+		}
+		dir := cmp.Compare(key, root.key)
+		if dir == 0 {
+			return root.loadValue()
+		}
+
+		version := root.version.Load()
+		if isShrinkingOrUnlinked(version) {
+			root.waitUntilShrinkCompleted(version)
+		} else if root == t.rootHolder.right.Load() {
+			if value, exist, retry := t.attemptGet(key, root, dir, version); !retry {
+				return value, exist
+			}
+		}
+	}
+}
+
+// attemptGet continues the search below node, which was reached while it had
+// nodeVersion. It asks the caller to retry if node has shrunk meanwhile.
+func (t *AVLTree[T, K]) attemptGet(key K, node *AVLNode[T, K], dir int, nodeVersion int64) (value T, exist, retry bool) {
+	for {
+		child := node.child(dir)
+		if child == nil {
+			if node.version.Load() != nodeVersion {
+				return value, false, true
+			}
+			return value, false, false
+		}
+
+		childDir := cmp.Compare(key, child.key)
+		if childDir == 0 {
+			value, exist = child.loadValue()
+			return value, exist, false
+		}
+
+		childVersion := child.version.Load()
+		if isShrinkingOrUnlinked(childVersion) {
+			child.waitUntilShrinkCompleted(childVersion)
+		} else if child == node.child(dir) {
+			// The second read of the edge is protected by childVersion, and
+			// the check below validates the way we came to node, so from now
+			// on shrinks of node cannot affect the search.
+			if node.version.Load() != nodeVersion {
+				return value, false, true
+			}
+			if value, exist, retry = t.attemptGet(key, child, childDir, childVersion); !retry {
+				return value, exist, false
+			}
+		}
+		if node.version.Load() != nodeVersion {
+			return value, false, true
+		}
+	}
+}
+
+func (t *AVLTree[T, K]) Insert(key K, value T) {
+	if prev := t.update(key, &value); prev == nil {
+		t.size.Add(1)
+	}
+}
+
+func (t *AVLTree[T, K]) Remove(key K) {
+	if prev := t.update(key, nil); prev != nil {
+		t.size.Add(-1)
+	}
+}
+
+// update stores newValue for the key, or removes the key if newValue is nil,
+// and returns the previous value.
+func (t *AVLTree[T, K]) update(key K, newValue *T) *T {
+	for {
+		root := t.rootHolder.right.Load()
+		if root == nil {
+			if newValue == nil || t.attemptInsertIntoEmpty(key, newValue) {
+				return nil
+			}
+			continue
+		}
+
+		version := root.version.Load()
+		if isShrinkingOrUnlinked(version) {
+			root.waitUntilShrinkCompleted(version)
+		} else if root == t.rootHolder.right.Load() {
+			if prev, retry := t.attemptUpdate(key, newValue, t.rootHolder, root, version); !retry {
+				return prev
+			}
+		}
+	}
+}
+
+func (t *AVLTree[T, K]) attemptInsertIntoEmpty(key K, value *T) bool {
+	t.rootHolder.Lock()
+	defer t.rootHolder.Unlock()
+
+	if t.rootHolder.right.Load() != nil {
+		return false
+	}
+	t.rootHolder.right.Store(newAVLNode(key, value, t.rootHolder))
+	t.rootHolder.height.Store(2)
+	return true
+}
+
+func (t *AVLTree[T, K]) attemptUpdate(key K, newValue *T, parent, node *AVLNode[T, K], nodeVersion int64) (prev *T, retry bool) {
+	dir := cmp.Compare(key, node.key)
+	if dir == 0 {
+		return t.attemptNodeUpdate(newValue, parent, node)
+	}
+
+	for {
+		child := node.child(dir)
+		if node.version.Load() != nodeVersion {
+			return nil, true
+		}
+
+		if child == nil {
+			if newValue == nil {
+				return nil, false
+			}
+			inserted, damaged, retry := t.attemptInsertLeaf(key, newValue, node, dir, nodeVersion)
+			if retry {
+				return nil, true
+			}
+			if inserted {
+				t.fixHeightAndRebalance(damaged)
+				return nil, false
+			}
+			continue
+		}
+
+		childVersion := child.version.Load()
+		if isShrinkingOrUnlinked(childVersion) {
+			child.waitUntilShrinkCompleted(childVersion)
+		} else if child == node.child(dir) {
+			if node.version.Load() != nodeVersion {
+				return nil, true
+			}
+			if prev, retry := t.attemptUpdate(key, newValue, node, child, childVersion); !retry {
+				return prev, false
+			}
+		}
+	}
+}
+
+// attemptInsertLeaf links a new leaf under node if the edge is still empty.
+// inserted is false if a concurrent insert has taken the edge first.
+func (t *AVLTree[T, K]) attemptInsertLeaf(key K, value *T, node *AVLNode[T, K], dir int, nodeVersion int64) (inserted bool, damaged *AVLNode[T, K], retry bool) {
+	node.Lock()
+	defer node.Unlock()
+
+	// Holding the lock, no future rotation can affect us, check past ones.
+	if node.version.Load() != nodeVersion {
+		return false, nil, true
+	}
+	if node.child(dir) != nil {
+		return false, nil, false
+	}
+	node.setChild(dir, newAVLNode(key, value, node))
+	return true, t.fixHeightLocked(node), false
+}
+
+// attemptNodeUpdate updates the value of node, which holds the key. parent
+// is only needed to unlink node, so it may be stale for in-place updates.
+func (t *AVLTree[T, K]) attemptNodeUpdate(newValue *T, parent, node *AVLNode[T, K]) (prev *T, retry bool) {
+	if newValue == nil && node.value.Load() == nil {
+		return nil, false
+	}
+
+	if newValue == nil && (node.left.Load() == nil || node.right.Load() == nil) {
+		// Removal that may unlink node, the parent must be locked first.
+		parent.Lock()
+		if isUnlinked(parent.version.Load()) || node.parent.Load() != parent {
+			parent.Unlock()
+			return nil, true
+		}
+
+		node.Lock()
+		prev = node.value.Load()
+		if prev == nil {
+			node.Unlock()
+			parent.Unlock()
+			return nil, false
+		}
+		if !t.attemptUnlinkLocked(parent, node) {
+			node.Unlock()
+			parent.Unlock()
+			return nil, true
+		}
+		node.Unlock()
+
+		damaged := t.fixHeightLocked(parent)
+		parent.Unlock()
+		t.fixHeightAndRebalance(damaged)
+		return prev, false
+	}
+
+	node.Lock()
+	defer node.Unlock()
+
+	if isUnlinked(node.version.Load()) {
+		return nil, true
+	}
+	prev = node.value.Load()
+	if newValue == nil && (node.left.Load() == nil || node.right.Load() == nil) {
+		// Unlinking became possible after the check above.
+		return nil, true
+	}
+	node.value.Store(newValue)
+	return prev, false
+}
+
+// attemptUnlinkLocked splices out node with at most one child. Both parent
+// and node must be locked; sizes and heights are not adjusted.
+func (t *AVLTree[T, K]) attemptUnlinkLocked(parent, node *AVLNode[T, K]) bool {
+	parentLeft, parentRight := parent.left.Load(), parent.right.Load()
+	if parentLeft != node && parentRight != node {
+		return false
+	}
+
+	left, right := node.left.Load(), node.right.Load()
+	if left != nil && right != nil {
+		return false
+	}
+	splice := left
+	if splice == nil {
+		splice = right
+	}
+
+	if parentLeft == node {
+		parent.left.Store(splice)
+	} else {
+		parent.right.Store(splice)
+	}
+	if splice != nil {
+		splice.parent.Store(parent)
+	}
+
+	node.version.Store(unlinkedVersion)
+	node.value.Store(nil)
+	return true
+}
+
+// nodeCondition returns the repair that node needs or its new height. The
+// reads are not atomic, but whoever changes a node afterwards repairs it.
+func (t *AVLTree[T, K]) nodeCondition(node *AVLNode[T, K]) int32 {
+	left, right := node.left.Load(), node.right.Load()
+	if (left == nil || right == nil) && node.value.Load() == nil {
+		return unlinkRequired
+	}
+
+	heightNode := node.height.Load()
+	heightLeft, heightRight := avlHeight(left), avlHeight(right)
+
+	newHeight := 1 + max(heightLeft, heightRight)
+	balance := heightLeft - heightRight
+	if balance < -1 || balance > 1 {
+		return rebalanceRequired
+	}
+	if heightNode != newHeight {
+		return newHeight
+	}
+	return nothingRequired
+}
+
+func (t *AVLTree[T, K]) fixHeightAndRebalance(node *AVLNode[T, K]) {
+	for node != nil && node.parent.Load() != nil {
+		condition := t.nodeCondition(node)
+		if condition == nothingRequired || isUnlinked(node.version.Load()) {
+			return
+		}
+
+		if condition != unlinkRequired && condition != rebalanceRequired {
+			node.Lock()
+			damaged := t.fixHeightLocked(node)
+			node.Unlock()
+			node = damaged
+			continue
+		}
+
+		parent := node.parent.Load()
+		parent.Lock()
+		if !isUnlinked(parent.version.Load()) && node.parent.Load() == parent {
+			node.Lock()
+			damaged := t.rebalanceLocked(parent, node)
+			node.Unlock()
+			node = damaged
+		}
+		parent.Unlock()
+	}
+}
+
+// fixHeightLocked repairs the height of a locked node and returns the lowest
+// damaged node this thread is responsible for, or nil.
+func (t *AVLTree[T, K]) fixHeightLocked(node *AVLNode[T, K]) *AVLNode[T, K] {
+	switch condition := t.nodeCondition(node); condition {
+	case rebalanceRequired, unlinkRequired:
+		return node
+	case nothingRequired:
+		return nil
+	default:
+		node.height.Store(condition)
+		return node.parent.Load()
+	}
+}
+
+// rebalanceLocked repairs node, both node and its parent must be locked. It
+// returns the next damaged node, or nil if no more repairs are needed.
+func (t *AVLTree[T, K]) rebalanceLocked(parent, node *AVLNode[T, K]) *AVLNode[T, K] {
+	left, right := node.left.Load(), node.right.Load()
+	if (left == nil || right == nil) && node.value.Load() == nil {
+		if t.attemptUnlinkLocked(parent, node) {
+			return t.fixHeightLocked(parent)
+		}
+		return node
+	}
+
+	heightNode := node.height.Load()
+	heightLeft, heightRight := avlHeight(left), avlHeight(right)
+	newHeight := 1 + max(heightLeft, heightRight)
+	balance := heightLeft - heightRight
+
+	switch {
+	case balance > 1:
+		return t.rebalanceToRightLocked(parent, node, left, heightRight)
+	case balance < -1:
+		return t.rebalanceToLeftLocked(parent, node, right, heightLeft)
+	case newHeight != heightNode:
+		node.height.Store(newHeight)
+		return t.fixHeightLocked(parent)
+	default:
+		return nil
+	}
+}
+
+func (t *AVLTree[T, K]) rebalanceToRightLocked(parent, node, left *AVLNode[T, K], heightRight int32) *AVLNode[T, K] {
+	left.Lock()
+	defer left.Unlock()
+
+	if left.height.Load()-heightRight <= 1 {
+		return node
+	}
+
+	leftRight := left.right.Load()
+	heightLeftLeft, heightLeftRight := avlHeight(left.left.Load()), avlHeight(leftRight)
+	if heightLeftLeft >= heightLeftRight {
+		return t.rotateRightLocked(parent, node, left, heightRight, heightLeftLeft, leftRight, heightLeftRight)
+	}
+
+	leftRight.Lock()
+	heightLeftRight = leftRight.height.Load()
+	if heightLeftLeft >= heightLeftRight {
+		defer leftRight.Unlock()
+		return t.rotateRightLocked(parent, node, left, heightRight, heightLeftLeft, leftRight, heightLeftRight)
+	}
+	// Do the double rotation only if it leaves left balanced and not an
+	// unnecessary routing node, otherwise fix left first.
+	heightLeftRightLeft := avlHeight(leftRight.left.Load())
+	balance := heightLeftLeft - heightLeftRightLeft
+	if balance >= -1 && balance <= 1 && !((heightLeftLeft == 0 || heightLeftRightLeft == 0) && left.value.Load() == nil) {
+		defer leftRight.Unlock()
+		return t.rotateRightOverLeftLocked(parent, node, left, heightRight, heightLeftLeft, leftRight, heightLeftRightLeft)
+	}
+	leftRight.Unlock()
+
+	return t.rebalanceToLeftLocked(node, left, leftRight, heightLeftLeft)
+}
+
+func (t *AVLTree[T, K]) rebalanceToLeftLocked(parent, node, right *AVLNode[T, K], heightLeft int32) *AVLNode[T, K] {
+	right.Lock()
+	defer right.Unlock()
+
+	if heightLeft-right.height.Load() >= -1 {
+		return node
+	}
+
+	rightLeft := right.left.Load()
+	heightRightLeft, heightRightRight := avlHeight(rightLeft), avlHeight(right.right.Load())
+	if heightRightRight >= heightRightLeft {
+		return t.rotateLeftLocked(parent, node, heightLeft, right, rightLeft, heightRightLeft, heightRightRight)
+	}
+
+	rightLeft.Lock()
+	heightRightLeft = rightLeft.height.Load()
+	if heightRightRight >= heightRightLeft {
+		defer rightLeft.Unlock()
+		return t.rotateLeftLocked(parent, node, heightLeft, right, rightLeft, heightRightLeft, heightRightRight)
+	}
+	heightRightLeftRight := avlHeight(rightLeft.right.Load())
+	balance := heightRightRight - heightRightLeftRight
+	if balance >= -1 && balance <= 1 && !((heightRightRight == 0 || heightRightLeftRight == 0) && right.value.Load() == nil) {
+		defer rightLeft.Unlock()
+		return t.rotateLeftOverRightLocked(parent, node, heightLeft, right, rightLeft, heightRightRight, heightRightLeftRight)
+	}
+	rightLeft.Unlock()
+
+	return t.rebalanceToRightLocked(node, right, rightLeft, heightRightRight)
+}
+
+func (t *AVLTree[T, K]) rotateRightLocked(parent, node, left *AVLNode[T, K], heightRight, heightLeftLeft int32, leftRight *AVLNode[T, K], heightLeftRight int32) *AVLNode[T, K] {
+	nodeVersion := node.version.Load()
+	node.version.Store(beginShrink(nodeVersion))
+
+	node.left.Store(leftRight)
+	if leftRight != nil {
+		leftRight.parent.Store(node)
+	}
+	left.right.Store(node)
+	node.parent.Store(left)
+	parent.replaceChild(node, left)
+	left.parent.Store(parent)
+
+	newHeightNode := 1 + max(heightLeftRight, heightRight)
+	node.height.Store(newHeightNode)
+	left.height.Store(1 + max(heightLeftLeft, newHeightNode))
+
+	node.version.Store(endShrink(nodeVersion))
+
+	// node is the deepest damaged node, then left and parent.
+	if balance := heightLeftRight - heightRight; balance < -1 || balance > 1 {
+		return node
+	}
+	if (leftRight == nil || heightRight == 0) && node.value.Load() == nil {
+		return node
+	}
+	if balance := heightLeftLeft - newHeightNode; balance < -1 || balance > 1 {
+		return left
+	}
+	if heightLeftLeft == 0 && left.value.Load() == nil {
+		return left
+	}
+	return t.fixHeightLocked(parent)
+}
+
+func (t *AVLTree[T, K]) rotateLeftLocked(parent, node *AVLNode[T, K], heightLeft int32, right, rightLeft *AVLNode[T, K], heightRightLeft, heightRightRight int32) *AVLNode[T, K] {
+	nodeVersion := node.version.Load()
+	node.version.Store(beginShrink(nodeVersion))
+
+	node.right.Store(rightLeft)
+	if rightLeft != nil {
+		rightLeft.parent.Store(node)
+	}
+	right.left.Store(node)
+	node.parent.Store(right)
+	parent.replaceChild(node, right)
+	right.parent.Store(parent)
+
+	newHeightNode := 1 + max(heightLeft, heightRightLeft)
+	node.height.Store(newHeightNode)
+	right.height.Store(1 + max(newHeightNode, heightRightRight))
+
+	node.version.Store(endShrink(nodeVersion))
+
+	if balance := heightRightLeft - heightLeft; balance < -1 || balance > 1 {
+		return node
+	}
+	if (rightLeft == nil || heightLeft == 0) && node.value.Load() == nil {
+		return node
+	}
+	if balance := heightRightRight - newHeightNode; balance < -1 || balance > 1 {
+		return right
+	}
+	if heightRightRight == 0 && right.value.Load() == nil {
+		return right
+	}
+	return t.fixHeightLocked(parent)
+}
+
+func (t *AVLTree[T, K]) rotateRightOverLeftLocked(parent, node, left *AVLNode[T, K], heightRight, heightLeftLeft int32, leftRight *AVLNode[T, K], heightLeftRightLeft int32) *AVLNode[T, K] {
+	nodeVersion := node.version.Load()
+	leftVersion := left.version.Load()
+
+	leftRightLeft, leftRightRight := leftRight.left.Load(), leftRight.right.Load()
+	heightLeftRightRight := avlHeight(leftRightRight)
+
+	node.version.Store(beginShrink(nodeVersion))
+	left.version.Store(beginShrink(leftVersion))
+
+	node.left.Store(leftRightRight)
+	if leftRightRight != nil {
+		leftRightRight.parent.Store(node)
+	}
+	left.right.Store(leftRightLeft)
+	if leftRightLeft != nil {
+		leftRightLeft.parent.Store(left)
+	}
+	leftRight.left.Store(left)
+	left.parent.Store(leftRight)
+	leftRight.right.Store(node)
+	node.parent.Store(leftRight)
+	parent.replaceChild(node, leftRight)
+	leftRight.parent.Store(parent)
+
+	newHeightNode := 1 + max(heightLeftRightRight, heightRight)
+	node.height.Store(newHeightNode)
+	newHeightLeft := 1 + max(heightLeftLeft, heightLeftRightLeft)
+	left.height.Store(newHeightLeft)
+	leftRight.height.Store(1 + max(newHeightLeft, newHeightNode))
+
+	node.version.Store(endShrink(nodeVersion))
+	left.version.Store(endShrink(leftVersion))
+
+	if balance := heightLeftRightRight - heightRight; balance < -1 || balance > 1 {
+		return node
+	}
+	if (leftRightRight == nil || heightRight == 0) && node.value.Load() == nil {
+		return node
+	}
+	if balance := newHeightLeft - newHeightNode; balance < -1 || balance > 1 {
+		return leftRight
+	}
+	return t.fixHeightLocked(parent)
+}
+
+func (t *AVLTree[T, K]) rotateLeftOverRightLocked(parent, node *AVLNode[T, K], heightLeft int32, right, rightLeft *AVLNode[T, K], heightRightRight, heightRightLeftRight int32) *AVLNode[T, K] {
+	nodeVersion := node.version.Load()
+	rightVersion := right.version.Load()
+
+	rightLeftLeft, rightLeftRight := rightLeft.left.Load(), rightLeft.right.Load()
+	heightRightLeftLeft := avlHeight(rightLeftLeft)
+
+	node.version.Store(beginShrink(nodeVersion))
+	right.version.Store(beginShrink(rightVersion))
+
+	node.right.Store(rightLeftLeft)
+	if rightLeftLeft != nil {
+		rightLeftLeft.parent.Store(node)
+	}
+	right.left.Store(rightLeftRight)
+	if rightLeftRight != nil {
+		rightLeftRight.parent.Store(right)
+	}
+	rightLeft.right.Store(right)
+	right.parent.Store(rightLeft)
+	rightLeft.left.Store(node)
+	node.parent.Store(rightLeft)
+	parent.replaceChild(node, rightLeft)
+	rightLeft.parent.Store(parent)
+
+	newHeightNode := 1 + max(heightLeft, heightRightLeftLeft)
+	node.height.Store(newHeightNode)
+	newHeightRight := 1 + max(heightRightLeftRight, heightRightRight)
+	right.height.Store(newHeightRight)
+	rightLeft.height.Store(1 + max(newHeightNode, newHeightRight))
+
+	node.version.Store(endShrink(nodeVersion))
+	right.version.Store(endShrink(rightVersion))
+
+	if balance := heightRightLeftLeft - heightLeft; balance < -1 || balance > 1 {
+		return node
+	}
+	if (rightLeftLeft == nil || heightLeft == 0) && node.value.Load() == nil {
+		return node
+	}
+	if balance := newHeightRight - newHeightNode; balance < -1 || balance > 1 {
+		return rightLeft
+	}
+	return t.fixHeightLocked(parent)
+}
+
+// All finds each successor with a hand-over-hand search, skipping routing nodes.
+func (t *AVLTree[T, K]) All() iter.Seq2[K, T] {
+	return ascend(t.Min, t.ceiling)
+}
+
+// Range is All restricted to lo <= key < hi.
+func (t *AVLTree[T, K]) Range(lo, hi K) iter.Seq2[K, T] {
+	return ascendRange(lo, hi, t.ceiling)
+}
+
+func (t *AVLTree[T, K]) Min() (key K, value T, exist bool) {
+	key, ptr, exist := t.searchLocked(func(K) (bool, int) { return true, -1 })
+	if !exist || ptr != nil {
+		return key, derefValue(ptr), exist
+	}
+	return t.ceiling(key, true)
+}
+
+func (t *AVLTree[T, K]) Max() (key K, value T, exist bool) {
+	key, ptr, exist := t.searchLocked(func(K) (bool, int) { return true, 1 })
+	if !exist || ptr != nil {
+		return key, derefValue(ptr), exist
+	}
+	return t.floor(key, true)
+}
+
+func (t *AVLTree[T, K]) Floor(key K) (K, T, bool) {
+	return t.floor(key, false)
+}
+
+func (t *AVLTree[T, K]) Ceiling(key K) (K, T, bool) {
+	return t.ceiling(key, false)
+}
+
+// Len returns the number of pairs. The counter is updated right after an
+// update finishes, so it may briefly lag behind concurrent operations.
+func (t *AVLTree[T, K]) Len() int {
+	return int(t.size.Load())
+}
+
+// Height returns the height of the tree, 0 for an empty one.
+func (t *AVLTree[T, K]) Height() int {
+	return t.rootHolder.right.Load().subtreeHeight()
+}
+
+func (n *AVLNode[T, K]) subtreeHeight() int {
+	if n == nil {
+		return 0
+	}
+	return 1 + max(n.left.Load().subtreeHeight(), n.right.Load().subtreeHeight())
+}
+
+func derefValue[T any](ptr *T) (value T) {
+	if ptr != nil {
+		value = *ptr
+	}
+	return
+}
+
+func (t *AVLTree[T, K]) ceiling(key K, strict bool) (K, T, bool) {
+	for {
+		resKey, ptr, exist := t.searchLocked(func(nodeKey K) (bool, int) {
+			switch c := cmp.Compare(key, nodeKey); {
+			case c == 0 && !strict:
+				return true, 0
+			case c < 0:
+				return true, -1
+			default:
+				return false, 1
+			}
+		})
+		if !exist || ptr != nil {
+			return resKey, derefValue(ptr), exist
+		}
+		// Skip the routing node.
+		key, strict = resKey, true
+	}
+}
+
+func (t *AVLTree[T, K]) floor(key K, strict bool) (K, T, bool) {
+	for {
+		resKey, ptr, exist := t.searchLocked(func(nodeKey K) (bool, int) {
+			switch c := cmp.Compare(key, nodeKey); {
+			case c == 0 && !strict:
+				return true, 0
+			case c > 0:
+				return true, 1
+			default:
+				return false, -1
+			}
+		})
+		if !exist || ptr != nil {
+			return resKey, derefValue(ptr), exist
+		}
+		key, strict = resKey, true
+	}
+}
+
+// searchLocked walks down from the root with hand-over-hand locking. visit
+// reports whether a node is a candidate and which child to follow next, 0 to
+// stop. The last candidate is returned, its value is nil for a routing node.
+func (t *AVLTree[T, K]) searchLocked(visit func(key K) (candidate bool, dir int)) (resKey K, resValue *T, exist bool) {
+	t.rootHolder.Lock()
+	node := t.rootHolder.right.Load()
+	if node != nil {
+		node.Lock()
+	}
+	t.rootHolder.Unlock()
+
+	for node != nil {
+		candidate, dir := visit(node.key)
+		if candidate {
+			resKey, resValue, exist = node.key, node.value.Load(), true
+		}
+
+		var nextNode *AVLNode[T, K]
+		if dir != 0 {
+			nextNode = node.child(dir)
+		}
+		if nextNode != nil {
+			nextNode.Lock()
+		}
+		node.Unlock()
+		node = nextNode
+	}
+	return
+}
+
+func (t *AVLTree[T, K]) IsValid() bool {
+	return t.rootHolder.right.Load().isValid(t.rootHolder, nil, nil)
+}
+
+// isValid checks the order of keys in (lo, hi) and the parent links.
+func (n *AVLNode[T, K]) isValid(parent *AVLNode[T, K], lo, hi *K) bool {
+	if n == nil {
+		return true
+	}
+	if n.parent.Load() != parent || (lo != nil && n.key <= *lo) || (hi != nil && n.key >= *hi) {
+		return false
+	}
+	return n.left.Load().isValid(n, lo, &n.key) && n.right.Load().isValid(n, &n.key, hi)
+}
@@ -2,12 +2,16 @@ package trees
 
 import (
 	"cmp"
-	"sync"
+	"iter"
+	"sync/atomic"
 )
 
 type FineGrainedSyncTree[T any, K cmp.Ordered] struct {
 	root  *FineNode[T, K]
-	mutex *sync.Mutex
+	size  atomic.Int64
+	mutex rwLocker
+	// newLock creates the locks of the tree and of its nodes.
+	newLock func() rwLocker
 }
 
 type FineNode[T any, K cmp.Ordered] struct {
@@ -15,7 +19,7 @@ type FineNode[T any, K cmp.Ordered] struct {
 	value T
 	left  *FineNode[T, K]
 	right *FineNode[T, K]
-	mutex *sync.Mutex
+	mutex rwLocker
 }
 
 func (fNd *FineNode[T, K]) Lock() {
@@ -26,62 +30,158 @@ func (fNd *FineNode[T, K]) Unlock() {
 	fNd.mutex.Unlock()
 }
 
-func NewFineGrainedSyncTree[T any, K cmp.Ordered]() *FineGrainedSyncTree[T, K] {
+func (fNd *FineNode[T, K]) RLock() {
+	fNd.mutex.RLock()
+}
+
+func (fNd *FineNode[T, K]) RUnlock() {
+	fNd.mutex.RUnlock()
+}
+
+// NewFineGrainedSyncTree returns a tree with a sync.Mutex in every node, or
+// the locks made by WithLockFactory. Locks that also implement RLock and
+// RUnlock are shared by readers, others are taken exclusively.
+func NewFineGrainedSyncTree[T any, K cmp.Ordered](opts ...Option) *FineGrainedSyncTree[T, K] {
+	return newFineGrainedSyncTree[T, K](applyOptions(opts, newMutex))
+}
+
+// NewRWFineGrainedSyncTree returns a tree whose nodes are guarded by
+// sync.RWMutex. Lookups, navigation and iteration descend hand-over-hand with
+// shared locks, so readers only wait for writers on the same path.
+func NewRWFineGrainedSyncTree[T any, K cmp.Ordered](opts ...Option) *FineGrainedSyncTree[T, K] {
+	return newFineGrainedSyncTree[T, K](applyOptions(opts, newRWMutex))
+}
+
+func newFineGrainedSyncTree[T any, K cmp.Ordered](o treeOptions) *FineGrainedSyncTree[T, K] {
+	newLock := func() rwLocker {
+		return asRWLocker(o.newLock())
+	}
 	return &FineGrainedSyncTree[T, K]{
-		root:  nil,
-		mutex: &sync.Mutex{},
+		root:    nil,
+		mutex:   newLock(),
+		newLock: newLock,
 	}
 }
 
 func (t *FineGrainedSyncTree[T, K]) Insert(key K, value T) {
 	currNode, parentNode := t.FinderNode(key)
-	insertNode := &FineNode[T, K]{key: key, value: value, mutex: &sync.Mutex{}}
+	defer t.UnlockParent(parentNode)
 
-	if parentNode == nil {
-		if currNode != nil {
-			currNode.value = value
-			defer currNode.Unlock()
-		} else {
-			t.root = insertNode
-		}
-		t.mutex.Unlock()
+	if currNode != nil {
+		defer currNode.Unlock()
+	}
+	t.storeAt(key, value, currNode, parentNode)
+}
+
+// storeAt sets the value of the locked position returned by FinderNode,
+// linking a new node under parentNode when currNode is nil. It does not
+// release any lock.
+func (t *FineGrainedSyncTree[T, K]) storeAt(key K, value T, currNode, parentNode *FineNode[T, K]) {
+	if currNode != nil {
+		currNode.value = value
 		return
+	}
 
+	insertNode := &FineNode[T, K]{key: key, value: value, mutex: t.newLock()}
+	if parentNode == nil {
+		t.root = insertNode
 	} else {
-		defer parentNode.Unlock()
-		if currNode != nil {
-			currNode.value = value
-			currNode.Unlock()
-			return
-		} else {
-			switch cmp.Compare(key, parentNode.key) {
-			case -1:
-				parentNode.left = insertNode
-			case 1:
-				parentNode.right = insertNode
-			default:
-				panic("this should not happen: parent.key = insert key")
-			}
-			return
+		switch cmp.Compare(key, parentNode.key) {
+		case -1:
+			parentNode.left = insertNode
+		case 1:
+			parentNode.right = insertNode
+		default:
+			panic("this should not happen: parent.key = insert key")
 		}
 	}
+	t.size.Add(1)
 }
 
 func (t *FineGrainedSyncTree[T, K]) Find(key K) (value T, exist bool) {
-	currNode, parentNode := t.FinderNode(key)
+	currNode := t.rLockRoot()
 
-	if parentNode == nil {
-		defer t.mutex.Unlock()
-	} else {
-		defer parentNode.Unlock()
+	for currNode != nil {
+		var nextNode *FineNode[T, K]
+		switch cmp.Compare(key, currNode.key) {
+		case -1:
+			nextNode = currNode.left
+		case 1:
+			nextNode = currNode.right
+		case 0:
+			defer currNode.RUnlock()
+			return currNode.value, true
+		}
+
+		if nextNode != nil {
+			nextNode.RLock()
+		}
+		currNode.RUnlock()
+		currNode = nextNode
 	}
+	return
+}
+
+func (t *FineGrainedSyncTree[T, K]) LoadOrStore(key K, value T) (actual T, loaded bool) {
+	currNode, parentNode := t.FinderNode(key)
+	defer t.UnlockParent(parentNode)
 
 	if currNode != nil {
 		defer currNode.Unlock()
-		value = currNode.value
-		return value, true
+		return currNode.value, true
 	}
-	return
+	t.storeAt(key, value, nil, parentNode)
+	return value, false
+}
+
+func (t *FineGrainedSyncTree[T, K]) CompareAndSwap(key K, old, new T) bool {
+	currNode, parentNode := t.FinderNode(key)
+	defer t.UnlockParent(parentNode)
+
+	if currNode == nil {
+		return false
+	}
+	defer currNode.Unlock()
+	if any(currNode.value) != any(old) {
+		return false
+	}
+	currNode.value = new
+	return true
+}
+
+func (t *FineGrainedSyncTree[T, K]) Compute(key K, f func(old T, loaded bool) (T, bool)) (actual T, present bool) {
+	currNode, parentNode := t.FinderNode(key)
+	defer t.UnlockParent(parentNode)
+
+	if currNode != nil {
+		actual = currNode.value
+	}
+	actual, present = f(actual, currNode != nil)
+
+	if present {
+		if currNode != nil {
+			defer currNode.Unlock()
+		}
+		t.storeAt(key, actual, currNode, parentNode)
+		return actual, true
+	}
+	if currNode != nil {
+		t.removeAt(currNode, parentNode)
+	}
+	var nilVal T
+	return nilVal, false
+}
+
+func (t *FineGrainedSyncTree[T, K]) LoadAndDelete(key K) (value T, loaded bool) {
+	currNode, parentNode := t.FinderNode(key)
+	defer t.UnlockParent(parentNode)
+
+	if currNode == nil {
+		return
+	}
+	value = currNode.value
+	t.removeAt(currNode, parentNode)
+	return value, true
 }
 
 func (t *FineGrainedSyncTree[T, K]) UnlockParent(parent *FineNode[T, K]) {
@@ -100,6 +200,13 @@ func (t *FineGrainedSyncTree[T, K]) Remove(key K) {
 	if currNode == nil {
 		return
 	}
+	t.removeAt(currNode, parentNode)
+}
+
+// removeAt unlinks the locked currNode returned by FinderNode. The parent
+// stays locked; currNode is unlocked only if it remains in the tree.
+func (t *FineGrainedSyncTree[T, K]) removeAt(currNode, parentNode *FineNode[T, K]) {
+	t.size.Add(-1)
 
 	switch {
 	case currNode.left == nil && currNode.right == nil:
@@ -156,7 +263,7 @@ func (t *FineGrainedSyncTree[T, K]) Remove(key K) {
 }
 
 func NewFineNode[T any, K cmp.Ordered]() *FineNode[T, K] {
-	return &FineNode[T, K]{mutex: &sync.Mutex{}}
+	return &FineNode[T, K]{mutex: asRWLocker(newMutex())}
 }
 
 func (t *FineGrainedSyncTree[T, K]) FinderNode(key K) (currentNode *FineNode[T, K], parentNode *FineNode[T, K]) {
@@ -202,6 +309,120 @@ func (t *FineGrainedSyncTree[T, K]) FinderNode(key K) (currentNode *FineNode[T,
 	return
 }
 
+// All finds each successor with a hand-over-hand descent.
+func (t *FineGrainedSyncTree[T, K]) All() iter.Seq2[K, T] {
+	return ascend(t.Min, t.ceiling)
+}
+
+// Range is All restricted to lo <= key < hi.
+func (t *FineGrainedSyncTree[T, K]) Range(lo, hi K) iter.Seq2[K, T] {
+	return ascendRange(lo, hi, t.ceiling)
+}
+
+// rLockRoot returns the root node locked for reading, or nil if the tree is
+// empty. The tree mutex is only held until the root is locked, as in
+// FinderNode.
+func (t *FineGrainedSyncTree[T, K]) rLockRoot() *FineNode[T, K] {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	if t.root == nil {
+		return nil
+	}
+	t.root.RLock()
+	return t.root
+}
+
+func (t *FineGrainedSyncTree[T, K]) Min() (key K, value T, exist bool) {
+	currNode := t.rLockRoot()
+	if currNode == nil {
+		return
+	}
+
+	for currNode.left != nil {
+		currNode.left.RLock()
+		currNode.RUnlock()
+		currNode = currNode.left
+	}
+	defer currNode.RUnlock()
+	return currNode.key, currNode.value, true
+}
+
+func (t *FineGrainedSyncTree[T, K]) Max() (key K, value T, exist bool) {
+	currNode := t.rLockRoot()
+	if currNode == nil {
+		return
+	}
+
+	for currNode.right != nil {
+		currNode.right.RLock()
+		currNode.RUnlock()
+		currNode = currNode.right
+	}
+	defer currNode.RUnlock()
+	return currNode.key, currNode.value, true
+}
+
+func (t *FineGrainedSyncTree[T, K]) Floor(key K) (resKey K, resValue T, exist bool) {
+	currNode := t.rLockRoot()
+
+	for currNode != nil {
+		var nextNode *FineNode[T, K]
+		switch cmp.Compare(key, currNode.key) {
+		case -1:
+			nextNode = currNode.left
+		case 1:
+			resKey, resValue, exist = currNode.key, currNode.value, true
+			nextNode = currNode.right
+		case 0:
+			defer currNode.RUnlock()
+			return currNode.key, currNode.value, true
+		}
+
+		if nextNode != nil {
+			nextNode.RLock()
+		}
+		currNode.RUnlock()
+		currNode = nextNode
+	}
+	return
+}
+
+func (t *FineGrainedSyncTree[T, K]) Ceiling(key K) (K, T, bool) {
+	return t.ceiling(key, false)
+}
+
+// Len returns the number of pairs. The counter is updated while the modified
+// position is still locked, so Len agrees with the order of Insert and Remove.
+func (t *FineGrainedSyncTree[T, K]) Len() int {
+	return int(t.size.Load())
+}
+
+func (t *FineGrainedSyncTree[T, K]) ceiling(key K, strict bool) (resKey K, resValue T, exist bool) {
+	currNode := t.rLockRoot()
+
+	for currNode != nil {
+		var nextNode *FineNode[T, K]
+		switch c := cmp.Compare(key, currNode.key); {
+		case c == 0 && !strict:
+			defer currNode.RUnlock()
+			return currNode.key, currNode.value, true
+		case c < 0:
+			resKey, resValue, exist = currNode.key, currNode.value, true
+			nextNode = currNode.left
+		default:
+			nextNode = currNode.right
+		}
+
+		if nextNode != nil {
+			nextNode.RLock()
+		}
+		currNode.RUnlock()
+		currNode = nextNode
+	}
+	return
+}
+
 func (t *FineGrainedSyncTree[T, K]) IsValid() bool {
 	return t.root.isValid()
 }
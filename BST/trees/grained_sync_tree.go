@@ -2,12 +2,13 @@ package trees
 
 import (
 	"cmp"
-	"sync"
+	"iter"
 )
 
 type GrainedSyncTree[T any, K cmp.Ordered] struct {
 	root  *Node[T, K]
-	mutex *sync.Mutex
+	size  int
+	mutex rwLocker
 }
 
 type Node[T any, K cmp.Ordered] struct {
@@ -17,13 +18,26 @@ type Node[T any, K cmp.Ordered] struct {
 	right *Node[T, K]
 }
 
-func NewGrainedSyncTree[T any, K cmp.Ordered]() *GrainedSyncTree[T, K] {
+// NewGrainedSyncTree returns a tree guarded by a global sync.Mutex, or the
+// lock made by WithLockFactory.
+func NewGrainedSyncTree[T any, K cmp.Ordered](opts ...Option) *GrainedSyncTree[T, K] {
+	return newGrainedSyncTree[T, K](applyOptions(opts, newMutex))
+}
+
+// NewRWGrainedSyncTree returns a tree guarded by a global sync.RWMutex:
+// lookups, navigation and iteration snapshots share the lock, so only
+// modifications are serialized.
+func NewRWGrainedSyncTree[T any, K cmp.Ordered](opts ...Option) *GrainedSyncTree[T, K] {
+	return newGrainedSyncTree[T, K](applyOptions(opts, newRWMutex))
+}
+
+func newGrainedSyncTree[T any, K cmp.Ordered](o treeOptions) *GrainedSyncTree[T, K] {
 	return &GrainedSyncTree[T, K]{
 		root:  nil,
-		mutex: &sync.Mutex{},
+		mutex: asRWLocker(o.newLock()),
 	}
 }
-func (t *GrainedSyncTree[T, K]) find(key K) (value T, exist bool) {
+func (t *GrainedSyncTree[T, K]) findNode(key K) *Node[T, K] {
 	node := t.root
 
 	for node != nil {
@@ -33,37 +47,49 @@ func (t *GrainedSyncTree[T, K]) find(key K) (value T, exist bool) {
 		case 1:
 			node = node.right
 		case 0:
-			return node.value, true
+			return node
 		}
 	}
+	return nil
+}
+
+func (t *GrainedSyncTree[T, K]) find(key K) (value T, exist bool) {
+	if node := t.findNode(key); node != nil {
+		return node.value, true
+	}
 	return value, false
 }
 
 func (t *GrainedSyncTree[T, K]) Find(key K) (T, bool) {
-	t.mutex.Lock()
-	defer t.mutex.Unlock()
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
 	return t.find(key)
 }
 
 func (t *GrainedSyncTree[T, K]) Insert(key K, value T) {
 	t.mutex.Lock()
 	defer t.mutex.Unlock()
-	t.root = insert(t.root, key, value)
+	var added bool
+	t.root, added = insert(t.root, key, value)
+	if added {
+		t.size++
+	}
 }
 
-func insert[T any, K cmp.Ordered](node *Node[T, K], key K, value T) *Node[T, K] {
+func insert[T any, K cmp.Ordered](node *Node[T, K], key K, value T) (*Node[T, K], bool) {
 	if node == nil {
-		return &Node[T, K]{key: key, value: value}
+		return &Node[T, K]{key: key, value: value}, true
 	}
+	var added bool
 	switch cmp.Compare(key, node.key) {
 	case -1:
-		node.left = insert(node.left, key, value)
+		node.left, added = insert(node.left, key, value)
 	case 1:
-		node.right = insert(node.right, key, value)
+		node.right, added = insert(node.right, key, value)
 	case 0:
 		node.value = value
 	}
-	return node
+	return node, added
 }
 
 func (t *GrainedSyncTree[T, K]) Remove(key K) {
@@ -73,6 +99,69 @@ func (t *GrainedSyncTree[T, K]) Remove(key K) {
 	t.root = t.remove(key, t.root)
 }
 
+func (t *GrainedSyncTree[T, K]) LoadOrStore(key K, value T) (actual T, loaded bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if node := t.findNode(key); node != nil {
+		return node.value, true
+	}
+	t.root, _ = insert(t.root, key, value)
+	t.size++
+	return value, false
+}
+
+func (t *GrainedSyncTree[T, K]) CompareAndSwap(key K, old, new T) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	node := t.findNode(key)
+	if node == nil || any(node.value) != any(old) {
+		return false
+	}
+	node.value = new
+	return true
+}
+
+func (t *GrainedSyncTree[T, K]) Compute(key K, f func(old T, loaded bool) (T, bool)) (actual T, present bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	node := t.findNode(key)
+	if node != nil {
+		actual = node.value
+	}
+	actual, present = f(actual, node != nil)
+
+	switch {
+	case present && node != nil:
+		node.value = actual
+	case present:
+		t.root, _ = insert(t.root, key, actual)
+		t.size++
+	case node != nil:
+		t.root = t.remove(key, t.root)
+	}
+	if !present {
+		var nilVal T
+		return nilVal, false
+	}
+	return actual, true
+}
+
+func (t *GrainedSyncTree[T, K]) LoadAndDelete(key K) (value T, loaded bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	node := t.findNode(key)
+	if node == nil {
+		return
+	}
+	value = node.value
+	t.root = t.remove(key, t.root)
+	return value, true
+}
+
 func (t *GrainedSyncTree[T, K]) remove(key K, node *Node[T, K]) *Node[T, K] {
 
 	if node == nil {
@@ -92,8 +181,10 @@ func (t *GrainedSyncTree[T, K]) remove(key K, node *Node[T, K]) *Node[T, K] {
 			node.right = t.remove(minRightNode.key, node.right)
 		} else if node.left == nil {
 			node = node.right
+			t.size--
 		} else {
 			node = node.left
+			t.size--
 		}
 	}
 
@@ -107,6 +198,132 @@ func (t *GrainedSyncTree[T, K]) min(node *Node[T, K]) *Node[T, K] {
 	return node
 }
 
+func (t *GrainedSyncTree[T, K]) max(node *Node[T, K]) *Node[T, K] {
+	for node.right != nil {
+		node = node.right
+	}
+	return node
+}
+
+func (t *GrainedSyncTree[T, K]) Min() (key K, value T, exist bool) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	if t.root == nil {
+		return
+	}
+	node := t.min(t.root)
+	return node.key, node.value, true
+}
+
+func (t *GrainedSyncTree[T, K]) Max() (key K, value T, exist bool) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	if t.root == nil {
+		return
+	}
+	node := t.max(t.root)
+	return node.key, node.value, true
+}
+
+func (t *GrainedSyncTree[T, K]) Floor(key K) (resKey K, resValue T, exist bool) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	node := t.root
+	for node != nil {
+		switch cmp.Compare(key, node.key) {
+		case -1:
+			node = node.left
+		case 1:
+			resKey, resValue, exist = node.key, node.value, true
+			node = node.right
+		case 0:
+			return node.key, node.value, true
+		}
+	}
+	return
+}
+
+func (t *GrainedSyncTree[T, K]) Ceiling(key K) (resKey K, resValue T, exist bool) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	node := t.root
+	for node != nil {
+		switch cmp.Compare(key, node.key) {
+		case -1:
+			resKey, resValue, exist = node.key, node.value, true
+			node = node.left
+		case 1:
+			node = node.right
+		case 0:
+			return node.key, node.value, true
+		}
+	}
+	return
+}
+
+func (t *GrainedSyncTree[T, K]) Len() int {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	return t.size
+}
+
+// All iterates over a snapshot copied under the global lock when it starts.
+func (t *GrainedSyncTree[T, K]) All() iter.Seq2[K, T] {
+	return func(yield func(K, T) bool) {
+		t.mutex.RLock()
+		snapshot := t.root.appendInOrder(nil, nil, nil)
+		t.mutex.RUnlock()
+
+		for _, e := range snapshot {
+			if !yield(e.key, e.value) {
+				return
+			}
+		}
+	}
+}
+
+// Range is All restricted to lo <= key < hi, only these pairs are copied.
+func (t *GrainedSyncTree[T, K]) Range(lo, hi K) iter.Seq2[K, T] {
+	return func(yield func(K, T) bool) {
+		t.mutex.RLock()
+		snapshot := t.root.appendInOrder(nil, &lo, &hi)
+		t.mutex.RUnlock()
+
+		for _, e := range snapshot {
+			if !yield(e.key, e.value) {
+				return
+			}
+		}
+	}
+}
+
+type entry[T any, K cmp.Ordered] struct {
+	key   K
+	value T
+}
+
+// appendInOrder appends the pairs with lo <= key < hi to dst in ascending
+// order. A nil bound means the range is unbounded on that side.
+func (nd *Node[T, K]) appendInOrder(dst []entry[T, K], lo, hi *K) []entry[T, K] {
+	if nd == nil {
+		return dst
+	}
+	if lo == nil || nd.key > *lo {
+		dst = nd.left.appendInOrder(dst, lo, hi)
+	}
+	if (lo == nil || nd.key >= *lo) && (hi == nil || nd.key < *hi) {
+		dst = append(dst, entry[T, K]{key: nd.key, value: nd.value})
+	}
+	if hi == nil || nd.key < *hi {
+		dst = nd.right.appendInOrder(dst, lo, hi)
+	}
+	return dst
+}
+
 func (t *GrainedSyncTree[T, K]) IsValid() bool {
 	return t.root.isValid()
 }
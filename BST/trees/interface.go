@@ -2,6 +2,7 @@ package trees
 
 import (
 	"cmp"
+	"iter"
 )
 
 type Tree[T any, K cmp.Ordered] interface {
@@ -9,4 +10,41 @@ type Tree[T any, K cmp.Ordered] interface {
 	Insert(K, T)
 	Remove(K)
 	IsValid() bool
+	// All iterates over every key-value pair in ascending key order. Unless
+	// the tree says otherwise, the iteration is weakly consistent: keys are
+	// yielded in strictly ascending order, every yielded pair was present at
+	// some moment during the iteration, and every key that is present and
+	// unchanged for the whole iteration is yielded. Keys inserted or removed
+	// concurrently may or may not be observed.
+	All() iter.Seq2[K, T]
+	// Range iterates over the pairs with lo <= key < hi in ascending key order.
+	Range(lo, hi K) iter.Seq2[K, T]
+	// Min and Max return the pairs with the smallest and the largest key.
+	Min() (K, T, bool)
+	Max() (K, T, bool)
+	// Floor returns the pair with the largest key <= the given one and Ceiling
+	// the pair with the smallest key >= the given one.
+	Floor(K) (K, T, bool)
+	Ceiling(K) (K, T, bool)
+	// Len returns the number of pairs in the tree.
+	Len() int
+}
+
+// AtomicTree is a Tree with read-modify-write operations that are atomic per
+// key, so callers do not need an external lock around Find and Insert.
+type AtomicTree[T any, K cmp.Ordered] interface {
+	Tree[T, K]
+	// LoadOrStore returns the existing value for the key if present and
+	// loaded == true. Otherwise, it stores and returns the given value.
+	LoadOrStore(key K, value T) (actual T, loaded bool)
+	// CompareAndSwap stores new for the key if its current value is equal to
+	// old. It panics if the values are not comparable, like sync.Map.
+	CompareAndSwap(key K, old, new T) (swapped bool)
+	// Compute calls f with the current value of the key, loaded reporting
+	// whether it exists. The returned value is stored if keep is true,
+	// otherwise the key is removed. Compute returns the resulting value and
+	// whether the key is present. f must not call methods of the tree.
+	Compute(key K, f func(old T, loaded bool) (value T, keep bool)) (actual T, present bool)
+	// LoadAndDelete removes the key and returns its previous value, if any.
+	LoadAndDelete(key K) (value T, loaded bool)
 }
@@ -0,0 +1,32 @@
+package trees
+
+import (
+	"cmp"
+	"iter"
+)
+
+// seekFunc returns the pair with the smallest key that is greater than key
+// (strict) or greater than or equal to key (not strict).
+type seekFunc[T any, K cmp.Ordered] func(key K, strict bool) (K, T, bool)
+
+// ascend builds an iterator that starts at the pair returned by first and
+// moves on by seeking the successor of the last yielded key. No lock is held
+// while yield runs, so the loop body may call back into the tree.
+func ascend[T any, K cmp.Ordered](first func() (K, T, bool), seek seekFunc[T, K]) iter.Seq2[K, T] {
+	return func(yield func(K, T) bool) {
+		key, value, ok := first()
+		for ok && yield(key, value) {
+			key, value, ok = seek(key, true)
+		}
+	}
+}
+
+// ascendRange is ascend restricted to lo <= key < hi.
+func ascendRange[T any, K cmp.Ordered](lo, hi K, seek seekFunc[T, K]) iter.Seq2[K, T] {
+	return func(yield func(K, T) bool) {
+		key, value, ok := seek(lo, false)
+		for ok && key < hi && yield(key, value) {
+			key, value, ok = seek(key, true)
+		}
+	}
+}
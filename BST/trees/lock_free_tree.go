@@ -0,0 +1,351 @@
+package trees
+
+import (
+	"cmp"
+	"iter"
+	"sync/atomic"
+)
+
+// LockFreeTree is the external (leaf-oriented) lock-free binary search tree of
+// Natarajan and Mittal. Keys live in leaves, internal nodes only route the
+// search. Remove first flags the edge to the leaf (its linearization point),
+// then tags the edge to the sibling and swings the edge of the closest
+// untagged ancestor to the sibling, unlinking both the parent and the leaf.
+// Any operation that runs into a flagged or tagged edge helps to finish the
+// removal before retrying.
+//
+// Go does not allow to steal bits of a pointer, so every edge is an atomic
+// pointer to an immutable record of the child together with its flag and tag.
+type LockFreeTree[T any, K cmp.Ordered] struct {
+	root *LockFreeNode[T, K]
+	size atomic.Int64
+}
+
+type LockFreeNode[T any, K cmp.Ordered] struct {
+	key   K
+	value T
+	// inf ranks the sentinel keys ∞1 < ∞2 < ∞3 above all real keys, it is 0
+	// for real keys.
+	inf   int
+	left  atomic.Pointer[lockFreeEdge[T, K]]
+	right atomic.Pointer[lockFreeEdge[T, K]]
+}
+
+type lockFreeEdge[T any, K cmp.Ordered] struct {
+	node *LockFreeNode[T, K]
+	// flag marks the leaf at the end of the edge as removed, tag freezes the
+	// edge to the sibling of a removed leaf until their parent is unlinked.
+	flag bool
+	tag  bool
+}
+
+// seekRecord is the result of seek: the path from the last untagged edge
+// (ancestor -> successor) down to the leaf where the search ends.
+type seekRecord[T any, K cmp.Ordered] struct {
+	ancestor  *LockFreeNode[T, K]
+	successor *LockFreeNode[T, K]
+	parent    *LockFreeNode[T, K]
+	leaf      *LockFreeNode[T, K]
+	leafEdge  *lockFreeEdge[T, K]
+}
+
+func NewLockFreeTree[T any, K cmp.Ordered]() *LockFreeTree[T, K] {
+	// Sentinels: R(∞3) -> {S(∞2), ∞3}, S(∞2) -> {∞1, ∞2}. Real keys are always
+	// inserted into the left subtree of S, so the tree is never empty.
+	s := newLockFreeInternal(&LockFreeNode[T, K]{inf: 2}, &LockFreeNode[T, K]{inf: 1}, &LockFreeNode[T, K]{inf: 2})
+	r := newLockFreeInternal(&LockFreeNode[T, K]{inf: 3}, s, &LockFreeNode[T, K]{inf: 3})
+	return &LockFreeTree[T, K]{root: r}
+}
+
+// newLockFreeInternal returns an internal node with the key of routing and
+// the given children.
+func newLockFreeInternal[T any, K cmp.Ordered](routing, left, right *LockFreeNode[T, K]) *LockFreeNode[T, K] {
+	node := &LockFreeNode[T, K]{key: routing.key, inf: routing.inf}
+	node.left.Store(&lockFreeEdge[T, K]{node: left})
+	node.right.Store(&lockFreeEdge[T, K]{node: right})
+	return node
+}
+
+func (n *LockFreeNode[T, K]) isLeaf() bool {
+	return n.left.Load() == nil
+}
+
+func (n *LockFreeNode[T, K]) hasKey(key K) bool {
+	return n.inf == 0 && n.key == key
+}
+
+// routesLeft reports whether key belongs to the left subtree of n.
+func (n *LockFreeNode[T, K]) routesLeft(key K) bool {
+	return n.inf > 0 || key < n.key
+}
+
+func (n *LockFreeNode[T, K]) less(other *LockFreeNode[T, K]) bool {
+	if n.inf != other.inf {
+		return n.inf < other.inf
+	}
+	return n.inf == 0 && n.key < other.key
+}
+
+func (n *LockFreeNode[T, K]) childEdge(key K) *atomic.Pointer[lockFreeEdge[T, K]] {
+	if n.routesLeft(key) {
+		return &n.left
+	}
+	return &n.right
+}
+
+func (t *LockFreeTree[T, K]) seek(key K) (record seekRecord[T, K]) {
+	record.ancestor = t.root
+	record.successor = t.root.left.Load().node
+	record.parent = record.successor
+
+	parentEdge := record.parent.left.Load()
+	record.leaf = parentEdge.node
+	currEdge := record.leaf.childEdge(key).Load()
+
+	for currEdge != nil {
+		if !parentEdge.tag {
+			record.ancestor = record.parent
+			record.successor = record.leaf
+		}
+		record.parent = record.leaf
+		record.leaf = currEdge.node
+		parentEdge = currEdge
+		currEdge = record.leaf.childEdge(key).Load()
+	}
+	record.leafEdge = parentEdge
+	return
+}
+
+func (t *LockFreeTree[T, K]) Find(key K) (value T, exist bool) {
+	record := t.seek(key)
+	if record.leaf.hasKey(key) && !record.leafEdge.flag {
+		return record.leaf.value, true
+	}
+	return
+}
+
+// Insert links a new internal node in place of the leaf where the search
+// ends. Leaves are immutable, so an existing key is overwritten by replacing
+// its leaf with a new one.
+func (t *LockFreeTree[T, K]) Insert(key K, value T) {
+	newLeaf := &LockFreeNode[T, K]{key: key, value: value}
+
+	for {
+		record := t.seek(key)
+		leaf, leafEdge := record.leaf, record.leafEdge
+		exist := leaf.hasKey(key)
+
+		replacement := newLeaf
+		if !exist {
+			if leaf.routesLeft(key) {
+				replacement = newLockFreeInternal(leaf, newLeaf, leaf)
+			} else {
+				replacement = newLockFreeInternal(newLeaf, leaf, newLeaf)
+			}
+		}
+
+		childAddr := record.parent.childEdge(key)
+		if !leafEdge.flag && !leafEdge.tag &&
+			childAddr.CompareAndSwap(leafEdge, &lockFreeEdge[T, K]{node: replacement}) {
+			if !exist {
+				t.size.Add(1)
+			}
+			return
+		}
+
+		if edge := childAddr.Load(); edge.node == leaf && (edge.flag || edge.tag) {
+			t.cleanup(key, record)
+		}
+	}
+}
+
+func (t *LockFreeTree[T, K]) Remove(key K) {
+	injecting := true
+	var leaf *LockFreeNode[T, K]
+
+	for {
+		record := t.seek(key)
+
+		if !injecting {
+			// The flagged leaf has been unlinked by another operation.
+			if record.leaf != leaf || t.cleanup(key, record) {
+				return
+			}
+			continue
+		}
+
+		leaf = record.leaf
+		if !leaf.hasKey(key) {
+			return
+		}
+
+		childAddr := record.parent.childEdge(key)
+		leafEdge := record.leafEdge
+		if !leafEdge.flag && !leafEdge.tag &&
+			childAddr.CompareAndSwap(leafEdge, &lockFreeEdge[T, K]{node: leaf, flag: true}) {
+			injecting = false
+			t.size.Add(-1)
+			if t.cleanup(key, record) {
+				return
+			}
+			continue
+		}
+
+		if edge := childAddr.Load(); edge.node == leaf && (edge.flag || edge.tag) {
+			t.cleanup(key, record)
+		}
+	}
+}
+
+// cleanup physically removes the flagged leaf below record.parent together
+// with the parent by swinging the ancestor's edge to the leaf's sibling.
+func (t *LockFreeTree[T, K]) cleanup(key K, record seekRecord[T, K]) bool {
+	successorAddr := record.ancestor.childEdge(key)
+
+	childAddr, siblingAddr := &record.parent.left, &record.parent.right
+	if !record.parent.routesLeft(key) {
+		childAddr, siblingAddr = siblingAddr, childAddr
+	}
+	if !childAddr.Load().flag {
+		// The leaf on the search path is the sibling of the flagged one.
+		siblingAddr = childAddr
+	}
+
+	sibling := siblingAddr.Load()
+	for !sibling.tag {
+		tagged := &lockFreeEdge[T, K]{node: sibling.node, flag: sibling.flag, tag: true}
+		if siblingAddr.CompareAndSwap(sibling, tagged) {
+			sibling = tagged
+		} else {
+			sibling = siblingAddr.Load()
+		}
+	}
+
+	successor := successorAddr.Load()
+	if successor.node != record.successor || successor.flag || successor.tag {
+		return false
+	}
+	return successorAddr.CompareAndSwap(successor, &lockFreeEdge[T, K]{node: sibling.node, flag: sibling.flag})
+}
+
+// All finds each successor with a lock-free search that skips flagged leaves.
+func (t *LockFreeTree[T, K]) All() iter.Seq2[K, T] {
+	return ascend(t.Min, t.ceiling)
+}
+
+// Range is All restricted to lo <= key < hi.
+func (t *LockFreeTree[T, K]) Range(lo, hi K) iter.Seq2[K, T] {
+	return ascendRange(lo, hi, t.ceiling)
+}
+
+func (t *LockFreeTree[T, K]) Min() (K, T, bool) {
+	return t.root.left.Load().firstLeaf().pair()
+}
+
+func (t *LockFreeTree[T, K]) Max() (K, T, bool) {
+	return t.root.left.Load().lastLeaf().pair()
+}
+
+func (t *LockFreeTree[T, K]) Floor(key K) (K, T, bool) {
+	return t.root.left.Load().floorLeaf(key).pair()
+}
+
+func (t *LockFreeTree[T, K]) Ceiling(key K) (K, T, bool) {
+	return t.ceiling(key, false)
+}
+
+func (t *LockFreeTree[T, K]) ceiling(key K, strict bool) (K, T, bool) {
+	return t.root.left.Load().ceilingLeaf(key, strict).pair()
+}
+
+// Len returns the number of pairs. The counter is updated right after the
+// linearizing CAS of Insert and Remove, so it may briefly lag behind them.
+func (t *LockFreeTree[T, K]) Len() int {
+	return int(t.size.Load())
+}
+
+func (n *LockFreeNode[T, K]) pair() (key K, value T, exist bool) {
+	if n == nil {
+		return
+	}
+	return n.key, n.value, true
+}
+
+// present reports whether the edge leads to a leaf with a real key that is
+// not removed.
+func (e *lockFreeEdge[T, K]) present() bool {
+	return e.node.inf == 0 && !e.flag
+}
+
+func (e *lockFreeEdge[T, K]) firstLeaf() *LockFreeNode[T, K] {
+	if e.node.isLeaf() {
+		if e.present() {
+			return e.node
+		}
+		return nil
+	}
+	if leaf := e.node.left.Load().firstLeaf(); leaf != nil {
+		return leaf
+	}
+	return e.node.right.Load().firstLeaf()
+}
+
+func (e *lockFreeEdge[T, K]) lastLeaf() *LockFreeNode[T, K] {
+	if e.node.isLeaf() {
+		if e.present() {
+			return e.node
+		}
+		return nil
+	}
+	if leaf := e.node.right.Load().lastLeaf(); leaf != nil {
+		return leaf
+	}
+	return e.node.left.Load().lastLeaf()
+}
+
+func (e *lockFreeEdge[T, K]) floorLeaf(key K) *LockFreeNode[T, K] {
+	node := e.node
+	if node.isLeaf() {
+		if e.present() && node.key <= key {
+			return node
+		}
+		return nil
+	}
+	if !node.routesLeft(key) {
+		if leaf := node.right.Load().floorLeaf(key); leaf != nil {
+			return leaf
+		}
+	}
+	return node.left.Load().floorLeaf(key)
+}
+
+func (e *lockFreeEdge[T, K]) ceilingLeaf(key K, strict bool) *LockFreeNode[T, K] {
+	node := e.node
+	if node.isLeaf() {
+		if e.present() && (key < node.key || (!strict && key == node.key)) {
+			return node
+		}
+		return nil
+	}
+	if node.routesLeft(key) {
+		if leaf := node.left.Load().ceilingLeaf(key, strict); leaf != nil {
+			return leaf
+		}
+	}
+	return node.right.Load().ceilingLeaf(key, strict)
+}
+
+func (t *LockFreeTree[T, K]) IsValid() bool {
+	return t.root.isValid(nil, nil)
+}
+
+// isValid checks that every key in the subtree lies in [lo, hi).
+func (n *LockFreeNode[T, K]) isValid(lo, hi *LockFreeNode[T, K]) bool {
+	if (lo != nil && n.less(lo)) || (hi != nil && !n.less(hi)) {
+		return false
+	}
+	if n.isLeaf() {
+		return true
+	}
+	return n.left.Load().node.isValid(lo, n) && n.right.Load().node.isValid(n, hi)
+}
@@ -2,61 +2,70 @@ package trees
 
 import (
 	"cmp"
+	"iter"
 	"sync"
+	"sync/atomic"
 )
 
 type OptimisticNode[T any, K cmp.Ordered] struct {
 	key   K
 	value T
-	left  *OptimisticNode[T, K]
-	right *OptimisticNode[T, K]
-	mutex *sync.Mutex
+	// The links are atomic because searches walk them without locks, they
+	// are changed only while the node is locked.
+	left  atomic.Pointer[OptimisticNode[T, K]]
+	right atomic.Pointer[OptimisticNode[T, K]]
+	mutex sync.Locker
 }
 
 type OptimisticTree[T any, K cmp.Ordered] struct {
-	root  *OptimisticNode[T, K]
-	mutex *sync.Mutex
+	root  atomic.Pointer[OptimisticNode[T, K]]
+	size  atomic.Int64
+	mutex sync.Locker
+	// newLock creates the locks of the tree and of its nodes.
+	newLock func() sync.Locker
 }
 
-func NewOptimisticSyncTree[T any, K cmp.Ordered]() *OptimisticTree[T, K] {
+// NewOptimisticSyncTree returns a tree with a sync.Mutex in every node, or
+// the locks made by WithLockFactory.
+func NewOptimisticSyncTree[T any, K cmp.Ordered](opts ...Option) *OptimisticTree[T, K] {
+	o := applyOptions(opts, newMutex)
 	return &OptimisticTree[T, K]{
-		root:  nil,
-		mutex: &sync.Mutex{},
+		mutex:   o.newLock(),
+		newLock: o.newLock,
 	}
 }
 
 func (t *OptimisticTree[T, K]) Insert(key K, value T) {
 	currNode, parentNode := t.FinderNode(key)
-	insertNode := &OptimisticNode[T, K]{key: key, value: value, mutex: &sync.Mutex{}}
+	defer t.UnlockParent(parentNode)
+	defer currNode.Unlock()
 
-	if parentNode == nil {
-		if currNode != nil {
-			currNode.value = value
-			defer currNode.Unlock()
-		} else {
-			t.root = insertNode
-		}
-		t.mutex.Unlock()
+	t.storeAt(key, value, currNode, parentNode)
+}
+
+// storeAt sets the value of the locked position returned by FinderNode,
+// linking a new node under parentNode when currNode is nil. It does not
+// release any lock.
+func (t *OptimisticTree[T, K]) storeAt(key K, value T, currNode, parentNode *OptimisticNode[T, K]) {
+	if currNode != nil {
+		currNode.value = value
 		return
+	}
 
+	insertNode := &OptimisticNode[T, K]{key: key, value: value, mutex: t.newLock()}
+	if parentNode == nil {
+		t.root.Store(insertNode)
 	} else {
-		defer parentNode.Unlock()
-		if currNode != nil {
-			currNode.value = value
-			currNode.Unlock()
-			return
-		} else {
-			switch cmp.Compare(key, parentNode.key) {
-			case -1:
-				parentNode.left = insertNode
-			case 1:
-				parentNode.right = insertNode
-			default:
-				panic("this should not happen: parent.key = insert key")
-			}
-			return
+		switch cmp.Compare(key, parentNode.key) {
+		case -1:
+			parentNode.left.Store(insertNode)
+		case 1:
+			parentNode.right.Store(insertNode)
+		default:
+			panic("this should not happen: parent.key = insert key")
 		}
 	}
+	t.size.Add(1)
 }
 
 func (t *OptimisticTree[T, K]) Find(key K) (value T, exist bool) {
@@ -86,58 +95,65 @@ func (t *OptimisticTree[T, K]) Remove(key K) {
 	}
 
 	defer currNode.Unlock()
+	t.removeAt(currNode, parentNode)
+}
 
-	switch {
-	case currNode.left == nil && currNode.right == nil:
-		if currNode == t.root {
-			t.root = nil
-		} else if parentNode.left != nil && parentNode.left == currNode {
-			parentNode.left = nil
-		} else {
-			parentNode.right = nil
-		}
-	case currNode.left != nil && currNode.right == nil:
-		if currNode == t.root {
-			t.root = currNode.left
-		} else if parentNode.left != nil && parentNode.left == currNode {
-			parentNode.left = currNode.left
-		} else {
-			parentNode.right = currNode.left
-		}
+// removeAt unlinks the locked currNode returned by FinderNode. Neither
+// currNode nor the parent is unlocked: waiters on a removed node must fail
+// their validation after acquiring it.
+func (t *OptimisticTree[T, K]) removeAt(currNode, parentNode *OptimisticNode[T, K]) {
+	t.size.Add(-1)
 
-	case currNode.right != nil && currNode.left == nil:
-		if currNode == t.root {
-			t.root = currNode.right
-		} else if parentNode.left != nil && parentNode.left == currNode {
-			parentNode.left = currNode.right
-		} else {
-			parentNode.right = currNode.right
-		}
+	left, right := currNode.left.Load(), currNode.right.Load()
+	switch {
+	case left == nil:
+		t.relink(currNode, parentNode, right)
+	case right == nil:
+		t.relink(currNode, parentNode, left)
 	default:
 		// 2 child nodes in current Node
-		currNode.right.Lock()
+		right.Lock()
 
 		tmpParent := currNode
-		tmpNode := currNode.right
-		for tmpNode.left != nil {
+		tmpNode := right
+		for next := tmpNode.left.Load(); next != nil; next = tmpNode.left.Load() {
 			tmpGrandParent := tmpParent
 			tmpParent = tmpNode
-			tmpNode.left.Lock()
-			tmpNode = tmpNode.left
+			next.Lock()
+			tmpNode = next
 			if tmpGrandParent != currNode {
 				tmpGrandParent.Unlock()
 			}
 		}
 
+		// The successor moves into a new node, keys of linked nodes never
+		// change, since searches read them without locks.
 		defer tmpNode.Unlock()
+		replaceNode := &OptimisticNode[T, K]{key: tmpNode.key, value: tmpNode.value, mutex: t.newLock()}
+		replaceNode.left.Store(left)
 		if tmpParent != currNode {
 			defer tmpParent.Unlock()
-			tmpParent.left = tmpNode.right
+			replaceNode.right.Store(right)
+			// The successor is reachable twice until it is unlinked, a
+			// search finds the upper node first.
+			t.relink(currNode, parentNode, replaceNode)
+			tmpParent.left.Store(tmpNode.right.Load())
 		} else {
-			tmpParent.right = tmpNode.right
+			replaceNode.right.Store(tmpNode.right.Load())
+			t.relink(currNode, parentNode, replaceNode)
 		}
-		currNode.value = tmpNode.value
-		currNode.key = tmpNode.key
+	}
+}
+
+// relink makes the parent of currNode, or the root, point to newNode.
+func (t *OptimisticTree[T, K]) relink(currNode, parentNode, newNode *OptimisticNode[T, K]) {
+	switch {
+	case currNode == t.root.Load():
+		t.root.Store(newNode)
+	case parentNode.left.Load() == currNode:
+		parentNode.left.Store(newNode)
+	default:
+		parentNode.right.Store(newNode)
 	}
 }
 
@@ -155,6 +171,64 @@ func (oNd *OptimisticNode[T, K]) Unlock() {
 	oNd.mutex.Unlock()
 }
 
+func (t *OptimisticTree[T, K]) LoadOrStore(key K, value T) (actual T, loaded bool) {
+	currNode, parentNode := t.FinderNode(key)
+	defer t.UnlockParent(parentNode)
+
+	if currNode != nil {
+		defer currNode.Unlock()
+		return currNode.value, true
+	}
+	t.storeAt(key, value, nil, parentNode)
+	return value, false
+}
+
+func (t *OptimisticTree[T, K]) CompareAndSwap(key K, old, new T) bool {
+	currNode, parentNode := t.FinderNode(key)
+	defer t.UnlockParent(parentNode)
+	defer currNode.Unlock()
+
+	if currNode == nil || any(currNode.value) != any(old) {
+		return false
+	}
+	currNode.value = new
+	return true
+}
+
+func (t *OptimisticTree[T, K]) Compute(key K, f func(old T, loaded bool) (T, bool)) (actual T, present bool) {
+	currNode, parentNode := t.FinderNode(key)
+	defer t.UnlockParent(parentNode)
+	defer currNode.Unlock()
+
+	if currNode != nil {
+		actual = currNode.value
+	}
+	actual, present = f(actual, currNode != nil)
+
+	if present {
+		t.storeAt(key, actual, currNode, parentNode)
+		return actual, true
+	}
+	if currNode != nil {
+		t.removeAt(currNode, parentNode)
+	}
+	var nilVal T
+	return nilVal, false
+}
+
+func (t *OptimisticTree[T, K]) LoadAndDelete(key K) (value T, loaded bool) {
+	currNode, parentNode := t.FinderNode(key)
+	defer t.UnlockParent(parentNode)
+	defer currNode.Unlock()
+
+	if currNode == nil {
+		return
+	}
+	value = currNode.value
+	t.removeAt(currNode, parentNode)
+	return value, true
+}
+
 func (t *OptimisticTree[T, K]) UnlockParent(parent *OptimisticNode[T, K]) {
 	if parent == nil {
 		t.mutex.Unlock()
@@ -167,11 +241,11 @@ func (t *OptimisticTree[T, K]) FinderNode(key K) (currentNode *OptimisticNode[T,
 	for {
 		t.mutex.Lock()
 
-		if t.root == nil {
+		if t.root.Load() == nil {
 			return
 		}
 
-		tmpNode := t.root
+		tmpNode := t.root.Load()
 		var tmpPrevNode *OptimisticNode[T, K] = nil
 
 		for tmpNode != nil && tmpNode.key != key {
@@ -180,9 +254,9 @@ func (t *OptimisticTree[T, K]) FinderNode(key K) (currentNode *OptimisticNode[T,
 
 			switch cmp.Compare(key, tmpNode.key) {
 			case -1:
-				tmpNode = tmpNode.left
+				tmpNode = tmpNode.left.Load()
 			case 1:
-				tmpNode = tmpNode.right
+				tmpNode = tmpNode.right.Load()
 			}
 			if tmpGrandNode == nil {
 				t.mutex.Unlock()
@@ -206,36 +280,152 @@ func (t *OptimisticTree[T, K]) FinderNode(key K) (currentNode *OptimisticNode[T,
 
 func (t *OptimisticTree[T, K]) Validate(key K, curr, parent *OptimisticNode[T, K]) bool {
 	if curr == nil && parent == nil {
-		return t.root == nil
+		return t.root.Load() == nil
 	}
-	tmpNode := t.root
+	tmpNode := t.root.Load()
 	var prevNode *OptimisticNode[T, K] = nil
 
 	for tmpNode != nil && tmpNode.key != key && tmpNode != curr {
 		prevNode = tmpNode
 		switch cmp.Compare(key, tmpNode.key) {
 		case -1:
-			tmpNode = tmpNode.left
+			tmpNode = tmpNode.left.Load()
 		case 1:
-			tmpNode = tmpNode.right
+			tmpNode = tmpNode.right.Load()
 		}
 	}
 	return curr == tmpNode && parent == prevNode
 }
 
+// All finds each successor without locks and validates it under the node lock.
+func (t *OptimisticTree[T, K]) All() iter.Seq2[K, T] {
+	return ascend(t.Min, t.ceiling)
+}
+
+// Range is All restricted to lo <= key < hi.
+func (t *OptimisticTree[T, K]) Range(lo, hi K) iter.Seq2[K, T] {
+	return ascendRange(lo, hi, t.ceiling)
+}
+
+func (t *OptimisticTree[T, K]) Min() (K, T, bool) {
+	return t.readValidated(t.leftmostNode)
+}
+
+func (t *OptimisticTree[T, K]) Max() (K, T, bool) {
+	return t.readValidated(t.rightmostNode)
+}
+
+func (t *OptimisticTree[T, K]) Floor(key K) (K, T, bool) {
+	return t.readValidated(func() *OptimisticNode[T, K] {
+		return t.floorNode(key)
+	})
+}
+
+func (t *OptimisticTree[T, K]) Ceiling(key K) (K, T, bool) {
+	return t.ceiling(key, false)
+}
+
+// Len returns the number of pairs. The counter is updated while the modified
+// position is locked, so Len agrees with the order of Insert and Remove.
+func (t *OptimisticTree[T, K]) Len() int {
+	return int(t.size.Load())
+}
+
+func (t *OptimisticTree[T, K]) ceiling(key K, strict bool) (K, T, bool) {
+	return t.readValidated(func() *OptimisticNode[T, K] {
+		return t.ceilingNode(key, strict)
+	})
+}
+
+// readValidated locks the node returned by search and reads it if a repeated
+// search still returns the same node. Like Validate, the searches do not take
+// the tree mutex: FinderNode may hold it while waiting for the root lock.
+func (t *OptimisticTree[T, K]) readValidated(search func() *OptimisticNode[T, K]) (key K, value T, exist bool) {
+	for {
+		node := search()
+		if node == nil {
+			return
+		}
+
+		node.Lock()
+		if search() == node {
+			defer node.Unlock()
+			return node.key, node.value, true
+		}
+		node.Unlock()
+	}
+}
+
+func (t *OptimisticTree[T, K]) leftmostNode() *OptimisticNode[T, K] {
+	node := t.root.Load()
+	for node != nil {
+		next := node.left.Load()
+		if next == nil {
+			return node
+		}
+		node = next
+	}
+	return nil
+}
+
+func (t *OptimisticTree[T, K]) rightmostNode() *OptimisticNode[T, K] {
+	node := t.root.Load()
+	for node != nil {
+		next := node.right.Load()
+		if next == nil {
+			return node
+		}
+		node = next
+	}
+	return nil
+}
+
+func (t *OptimisticTree[T, K]) floorNode(key K) (resNode *OptimisticNode[T, K]) {
+	node := t.root.Load()
+	for node != nil {
+		switch cmp.Compare(key, node.key) {
+		case -1:
+			node = node.left.Load()
+		case 1:
+			resNode = node
+			node = node.right.Load()
+		case 0:
+			return node
+		}
+	}
+	return
+}
+
+func (t *OptimisticTree[T, K]) ceilingNode(key K, strict bool) (resNode *OptimisticNode[T, K]) {
+	node := t.root.Load()
+	for node != nil {
+		switch c := cmp.Compare(key, node.key); {
+		case c == 0 && !strict:
+			return node
+		case c < 0:
+			resNode = node
+			node = node.left.Load()
+		default:
+			node = node.right.Load()
+		}
+	}
+	return
+}
+
 func (t *OptimisticTree[T, K]) IsValid() bool {
-	return t.root.isValid()
+	return t.root.Load().isValid()
 }
 
 func (oNd *OptimisticNode[T, K]) isValid() bool {
 	if oNd == nil {
 		return true
 	}
-	if oNd.left != nil && oNd.left.key >= oNd.key {
+	left, right := oNd.left.Load(), oNd.right.Load()
+	if left != nil && left.key >= oNd.key {
 		return false
 	}
-	if oNd.right != nil && oNd.right.key <= oNd.key {
+	if right != nil && right.key <= oNd.key {
 		return false
 	}
-	return oNd.left.isValid() && oNd.right.isValid()
+	return left.isValid() && right.isValid()
 }
@@ -0,0 +1,38 @@
+package trees
+
+import "sync"
+
+// Option configures the lock-based trees.
+type Option func(*treeOptions)
+
+type treeOptions struct {
+	newLock func() sync.Locker
+}
+
+// WithLockFactory makes the tree create its locks, the tree lock and one lock
+// per node, with newLock instead of allocating a sync.Mutex, or a
+// sync.RWMutex in the RW trees. The coarse and fine-grained trees share the
+// locks that also implement RLock and RUnlock between readers and take the
+// others exclusively, so an RW tree with a plain lock is the plain tree.
+func WithLockFactory(newLock func() sync.Locker) Option {
+	return func(o *treeOptions) {
+		o.newLock = newLock
+	}
+}
+
+// applyOptions applies opts over the default lock of the tree.
+func applyOptions(opts []Option, defaultLock func() sync.Locker) treeOptions {
+	o := treeOptions{newLock: defaultLock}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+func newMutex() sync.Locker {
+	return &sync.Mutex{}
+}
+
+func newRWMutex() sync.Locker {
+	return &sync.RWMutex{}
+}
@@ -0,0 +1,34 @@
+package trees
+
+import "sync"
+
+// rwLocker is the lock of the coarse and fine-grained trees. Lookups take it
+// with RLock, modifications with Lock.
+type rwLocker interface {
+	sync.Locker
+	RLock()
+	RUnlock()
+}
+
+// exclusiveLocker adapts a sync.Locker by serving RLock with Lock, so readers
+// of the plain trees exclude each other as well as writers.
+type exclusiveLocker struct {
+	sync.Locker
+}
+
+func (l exclusiveLocker) RLock() {
+	l.Lock()
+}
+
+func (l exclusiveLocker) RUnlock() {
+	l.Unlock()
+}
+
+// asRWLocker returns l itself if it already has shared locking, as
+// sync.RWMutex does, and an exclusiveLocker around it otherwise.
+func asRWLocker(l sync.Locker) rwLocker {
+	if rw, ok := l.(rwLocker); ok {
+		return rw
+	}
+	return exclusiveLocker{l}
+}
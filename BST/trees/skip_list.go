@@ -0,0 +1,332 @@
+package trees
+
+import (
+	"cmp"
+	"iter"
+	"math/bits"
+	"math/rand"
+	"sync/atomic"
+)
+
+const skipListMaxLevel = 24
+
+// SkipList is the lock-free skip list of Herlihy and Shavit (The Art of
+// Multiprocessor Programming, chapter 14). Every level is a lock-free list
+// whose links carry a removal mark; the bottom level holds all keys and the
+// upper levels are shortcuts. Searches snip marked nodes out of the levels
+// they pass.
+//
+// The value of a node is an atomic pointer: Insert of an existing key swaps
+// it, and Remove swaps it to nil, which is the linearization point of the
+// removal. The links of the node are marked afterwards, top level first.
+type SkipList[T any, K cmp.Ordered] struct {
+	head *SkipListNode[T, K]
+	size atomic.Int64
+}
+
+type SkipListNode[T any, K cmp.Ordered] struct {
+	key   K
+	value atomic.Pointer[T]
+	next  []atomic.Pointer[skipListRef[T, K]]
+}
+
+// skipListRef is an immutable link with the removal mark of its owner.
+// A nil node is the end of the level.
+type skipListRef[T any, K cmp.Ordered] struct {
+	node   *SkipListNode[T, K]
+	marked bool
+}
+
+func NewSkipList[T any, K cmp.Ordered]() *SkipList[T, K] {
+	return &SkipList[T, K]{head: newSkipListNode[T, K](*new(K), nil, skipListMaxLevel-1)}
+}
+
+func newSkipListNode[T any, K cmp.Ordered](key K, value *T, topLevel int) *SkipListNode[T, K] {
+	node := &SkipListNode[T, K]{key: key, next: make([]atomic.Pointer[skipListRef[T, K]], topLevel+1)}
+	node.value.Store(value)
+	for level := range node.next {
+		node.next[level].Store(&skipListRef[T, K]{})
+	}
+	return node
+}
+
+// randomLevel returns the top level of a new node, level i is reached with
+// probability 2^-i.
+func randomLevel() int {
+	return min(bits.TrailingZeros64(rand.Uint64()), skipListMaxLevel-1)
+}
+
+// casNext replaces the unmarked link from pred to succ with a link to node.
+func (pred *SkipListNode[T, K]) casNext(level int, succ, node *SkipListNode[T, K]) bool {
+	ref := pred.next[level].Load()
+	if ref.node != succ || ref.marked {
+		return false
+	}
+	return pred.next[level].CompareAndSwap(ref, &skipListRef[T, K]{node: node})
+}
+
+// markAll marks every link of the node, top level first.
+func (node *SkipListNode[T, K]) markAll() {
+	for level := len(node.next) - 1; level >= 0; level-- {
+		for {
+			ref := node.next[level].Load()
+			if ref.marked || node.next[level].CompareAndSwap(ref, &skipListRef[T, K]{node: ref.node, marked: true}) {
+				break
+			}
+		}
+	}
+}
+
+// find fills preds and succs with the nodes around the key on every level,
+// snipping marked nodes on the way, and reports whether succs[0] holds key.
+func (s *SkipList[T, K]) find(key K, preds, succs *[skipListMaxLevel]*SkipListNode[T, K]) bool {
+retry:
+	for {
+		pred := s.head
+		for level := skipListMaxLevel - 1; level >= 0; level-- {
+			curr := pred.next[level].Load().node
+			for curr != nil {
+				ref := curr.next[level].Load()
+				for ref.marked {
+					if !pred.casNext(level, curr, ref.node) {
+						continue retry
+					}
+					curr = ref.node
+					if curr == nil {
+						break
+					}
+					ref = curr.next[level].Load()
+				}
+				if curr == nil || curr.key >= key {
+					break
+				}
+				pred, curr = curr, ref.node
+			}
+			preds[level], succs[level] = pred, curr
+		}
+		return succs[0] != nil && succs[0].key == key
+	}
+}
+
+// search walks down without snipping and returns the last node on the bottom
+// level whose key is less than key (or not greater if inclusive), possibly
+// the head. Marked nodes are skipped.
+func (s *SkipList[T, K]) search(key K, inclusive bool) *SkipListNode[T, K] {
+	pred := s.head
+	for level := skipListMaxLevel - 1; level >= 0; level-- {
+		curr := pred.next[level].Load().node
+		for curr != nil {
+			ref := curr.next[level].Load()
+			if !ref.marked {
+				if curr.key > key || (!inclusive && curr.key == key) {
+					break
+				}
+				pred = curr
+			}
+			curr = ref.node
+		}
+	}
+	return pred
+}
+
+func (s *SkipList[T, K]) Find(key K) (value T, exist bool) {
+	pred := s.search(key, false)
+	for curr := pred.next[0].Load().node; curr != nil && curr.key <= key; curr = curr.next[0].Load().node {
+		if curr.key == key && !curr.next[0].Load().marked {
+			return curr.load()
+		}
+	}
+	return
+}
+
+func (node *SkipListNode[T, K]) load() (value T, exist bool) {
+	if ptr := node.value.Load(); ptr != nil {
+		return *ptr, true
+	}
+	return
+}
+
+func (s *SkipList[T, K]) Insert(key K, value T) {
+	var preds, succs [skipListMaxLevel]*SkipListNode[T, K]
+	topLevel := randomLevel()
+
+	for {
+		if s.find(key, &preds, &succs) {
+			node := succs[0]
+			for old := node.value.Load(); old != nil; old = node.value.Load() {
+				if node.value.CompareAndSwap(old, &value) {
+					return
+				}
+			}
+			// The node is being removed: help to unlink it and retry.
+			node.markAll()
+			continue
+		}
+
+		newNode := newSkipListNode(key, &value, topLevel)
+		for level := 0; level <= topLevel; level++ {
+			newNode.next[level].Store(&skipListRef[T, K]{node: succs[level]})
+		}
+		if !preds[0].casNext(0, succs[0], newNode) {
+			continue
+		}
+		s.size.Add(1)
+
+		for level := 1; level <= topLevel; level++ {
+			for {
+				ref := newNode.next[level].Load()
+				if ref.marked {
+					// Removed meanwhile, the remover unlinks what is linked.
+					return
+				}
+				if ref.node != succs[level] &&
+					!newNode.next[level].CompareAndSwap(ref, &skipListRef[T, K]{node: succs[level]}) {
+					continue
+				}
+				if preds[level].casNext(level, succs[level], newNode) {
+					break
+				}
+				if !s.find(key, &preds, &succs) || succs[0] != newNode {
+					return
+				}
+			}
+		}
+		return
+	}
+}
+
+func (s *SkipList[T, K]) Remove(key K) {
+	var preds, succs [skipListMaxLevel]*SkipListNode[T, K]
+
+	for {
+		if !s.find(key, &preds, &succs) {
+			return
+		}
+		node := succs[0]
+		old := node.value.Load()
+		if old != nil && !node.value.CompareAndSwap(old, nil) {
+			// Overwritten concurrently, try again.
+			continue
+		}
+		if old != nil {
+			s.size.Add(-1)
+		}
+		node.markAll()
+		s.find(key, &preds, &succs)
+		return
+	}
+}
+
+// All walks the bottom level and skips removed nodes.
+func (s *SkipList[T, K]) All() iter.Seq2[K, T] {
+	return s.ascend(s.head)
+}
+
+// Range is All restricted to lo <= key < hi with the same guarantees. The
+// first key is found with a skip list search, the rest by walking the bottom
+// level.
+func (s *SkipList[T, K]) Range(lo, hi K) iter.Seq2[K, T] {
+	return func(yield func(K, T) bool) {
+		for key, value := range s.ascend(s.search(lo, false)) {
+			if key >= hi || !yield(key, value) {
+				return
+			}
+		}
+	}
+}
+
+// ascend walks the bottom level from the successor of start. Links always
+// lead to greater keys, and a node removed after being reached still links to
+// its successor at the time of removal, so the walk never loses the rest of
+// the level.
+func (s *SkipList[T, K]) ascend(start *SkipListNode[T, K]) iter.Seq2[K, T] {
+	return func(yield func(K, T) bool) {
+		for curr := start.next[0].Load().node; curr != nil; curr = curr.next[0].Load().node {
+			value, exist := curr.load()
+			if !exist || curr.next[0].Load().marked {
+				continue
+			}
+			if !yield(curr.key, value) {
+				return
+			}
+		}
+	}
+}
+
+func (s *SkipList[T, K]) Min() (key K, value T, exist bool) {
+	for key, value = range s.All() {
+		return key, value, true
+	}
+	return
+}
+
+func (s *SkipList[T, K]) Max() (K, T, bool) {
+	pred := s.head
+	for level := skipListMaxLevel - 1; level >= 0; level-- {
+		for curr := pred.next[level].Load().node; curr != nil; curr = curr.next[level].Load().node {
+			if !curr.next[level].Load().marked {
+				pred = curr
+			}
+		}
+	}
+	return s.floorFrom(pred)
+}
+
+func (s *SkipList[T, K]) Floor(key K) (K, T, bool) {
+	return s.floorFrom(s.search(key, true))
+}
+
+// floorFrom returns node if it is present, otherwise its closest present
+// predecessor. Levels have no back links, so a removed node is skipped by a
+// new search for a strictly smaller key.
+func (s *SkipList[T, K]) floorFrom(node *SkipListNode[T, K]) (key K, value T, exist bool) {
+	for node != s.head {
+		if value, exist = node.load(); exist && !node.next[0].Load().marked {
+			return node.key, value, true
+		}
+		node = s.search(node.key, false)
+	}
+	return
+}
+
+func (s *SkipList[T, K]) Ceiling(key K) (resKey K, resValue T, exist bool) {
+	for resKey, resValue = range s.ascend(s.search(key, false)) {
+		return resKey, resValue, true
+	}
+	return
+}
+
+// Len returns the number of pairs. The counter is updated right after the
+// linearizing CAS of Insert and Remove, so it may briefly lag behind them.
+func (s *SkipList[T, K]) Len() int {
+	return int(s.size.Load())
+}
+
+// IsValid checks that every level is sorted and that every node on an upper
+// level is also linked on the bottom one, unless a Remove has marked it. The
+// upper levels are walked first: Insert links a node on the bottom level
+// before the others, and only a marked node leaves it, so the check also holds
+// under concurrent updates.
+func (s *SkipList[T, K]) IsValid() bool {
+	upper := make(map[*SkipListNode[T, K]]bool)
+	for level := skipListMaxLevel - 1; level >= 0; level-- {
+		var prev *SkipListNode[T, K]
+		for curr := s.head.next[level].Load().node; curr != nil; curr = curr.next[level].Load().node {
+			if prev != nil && prev.key >= curr.key {
+				return false
+			}
+			if level > 0 {
+				upper[curr] = true
+			} else {
+				delete(upper, curr)
+			}
+			prev = curr
+		}
+	}
+	for node := range upper {
+		if !node.next[0].Load().marked {
+			return false
+		}
+	}
+	return true
+}
@@ -1,12 +1,27 @@
 package benchmarks
 
 import (
+	"Treiber-stack/counter"
+	"Treiber-stack/forkjoin"
+	"Treiber-stack/queues"
+	"Treiber-stack/queues/MichaelScott"
+	"Treiber-stack/queues/Mutex"
+	"Treiber-stack/queues/TwoLock"
 	"Treiber-stack/stacks"
+	"Treiber-stack/stacks/Blocking"
+	"Treiber-stack/stacks/Bounded"
+	"Treiber-stack/stacks/FlatCombining"
+	"Treiber-stack/stacks/Relaxed"
 	"Treiber-stack/stacks/Simple"
 	"Treiber-stack/stacks/Treiber"
 	"Treiber-stack/stacks/optimizationTreiber"
+	"context"
+	"fmt"
+	"runtime"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 const countElem = 1_000_000
@@ -41,6 +56,13 @@ func BenchmarkNonConcurrent(b *testing.B) {
 			NonConcurrentPushAndPop(&optimizeTreiberStack)
 		}
 	})
+
+	b.Run("Flat-combining stack not concurrent", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			flatCombiningStack := FlatCombining.CreateFlatCombiningStack[int]()
+			NonConcurrentPushAndPop(&flatCombiningStack)
+		}
+	})
 }
 
 func littleConcurrent(stack stacks.Stack[int]) {
@@ -104,6 +126,13 @@ func BenchmarkLittleConcurrent(b *testing.B) {
 			littleConcurrent(&optimizeTreiberStack)
 		}
 	})
+
+	b.Run("Flat-combining stack little concurrent", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			flatCombiningStack := FlatCombining.CreateFlatCombiningStack[int]()
+			littleConcurrent(&flatCombiningStack)
+		}
+	})
 }
 
 func BenchmarkAllConcurrent(b *testing.B) {
@@ -120,6 +149,13 @@ func BenchmarkAllConcurrent(b *testing.B) {
 			allConcurrent(&optimizeTreiberStack)
 		}
 	})
+
+	b.Run("Flat-combining stack all concurrent", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			flatCombiningStack := FlatCombining.CreateFlatCombiningStack[int]()
+			allConcurrent(&flatCombiningStack)
+		}
+	})
 }
 
 func PushAndPopInRow(stack stacks.Stack[int]) {
@@ -176,6 +212,13 @@ func BenchmarkOptimizationCompare(b *testing.B) {
 		}
 	})
 
+	b.Run("Flat-combining stack push and pop in row", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			flatCombiningStack := FlatCombining.CreateFlatCombiningStack[int]()
+			PushAndPopInRow(&flatCombiningStack)
+		}
+	})
+
 	b.Run("TreiberStack random", func(b *testing.B) {
 		for i := 0; i < b.N; i++ {
 			treiberStack := Treiber.CreateTreiberStack[int]()
@@ -189,4 +232,481 @@ func BenchmarkOptimizationCompare(b *testing.B) {
 			PushPopConcurentRand(&optimizeTreiberStack)
 		}
 	})
+
+	b.Run("Flat-combining stack random", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			flatCombiningStack := FlatCombining.CreateFlatCombiningStack[int]()
+			PushPopConcurentRand(&flatCombiningStack)
+		}
+	})
+}
+
+// phases runs a scenario split into a push phase and a pop phase and returns
+// the duration of each, elimination can never succeed inside one of them.
+func phases(stack stacks.Stack[int], goroutineCount int) (push, pop time.Duration) {
+	run := func(f func(stack stacks.Stack[int])) time.Duration {
+		start := time.Now()
+		wg := sync.WaitGroup{}
+		wg.Add(goroutineCount)
+		for i := 0; i < goroutineCount; i++ {
+			go func() {
+				defer wg.Done()
+				f(stack)
+			}()
+		}
+		wg.Wait()
+		return time.Since(start)
+	}
+	opsCount := countElem / goroutineCount
+	push = run(func(stack stacks.Stack[int]) {
+		for j := 0; j < opsCount; j++ {
+			stack.Push(j)
+		}
+	})
+	pop = run(func(stack stacks.Stack[int]) {
+		for j := 0; j < opsCount; j++ {
+			stack.Pop()
+		}
+	})
+	return
+}
+
+// BenchmarkPhases compares the stacks in scenarios 2 (100 goroutines) and 3
+// (a goroutine per operation) and reports the time of each phase.
+func BenchmarkPhases(b *testing.B) {
+	scenarios := []struct {
+		name           string
+		goroutineCount int
+	}{
+		{"little concurrent", 100},
+		{"all concurrent", countElem},
+	}
+	for _, scenario := range scenarios {
+		b.Run("TreiberStack "+scenario.name, func(b *testing.B) {
+			var push, pop time.Duration
+			for i := 0; i < b.N; i++ {
+				treiberStack := Treiber.CreateTreiberStack[int]()
+				pushTime, popTime := phases(&treiberStack, scenario.goroutineCount)
+				push, pop = push+pushTime, pop+popTime
+			}
+			b.ReportMetric(float64(push.Milliseconds())/float64(b.N), "push-ms/op")
+			b.ReportMetric(float64(pop.Milliseconds())/float64(b.N), "pop-ms/op")
+		})
+
+		b.Run("Optimization back-off elimination treiberStack "+scenario.name, func(b *testing.B) {
+			var push, pop time.Duration
+			for i := 0; i < b.N; i++ {
+				optimizeTreiberStack := optimizationTreiber.CreateBackoffTreiberStack[int]()
+				pushTime, popTime := phases(&optimizeTreiberStack, scenario.goroutineCount)
+				push, pop = push+pushTime, pop+popTime
+			}
+			b.ReportMetric(float64(push.Milliseconds())/float64(b.N), "push-ms/op")
+			b.ReportMetric(float64(pop.Milliseconds())/float64(b.N), "pop-ms/op")
+		})
+
+		b.Run("Flat-combining stack "+scenario.name, func(b *testing.B) {
+			var push, pop time.Duration
+			for i := 0; i < b.N; i++ {
+				flatCombiningStack := FlatCombining.CreateFlatCombiningStack[int]()
+				pushTime, popTime := phases(&flatCombiningStack, scenario.goroutineCount)
+				push, pop = push+pushTime, pop+popTime
+			}
+			b.ReportMetric(float64(push.Milliseconds())/float64(b.N), "push-ms/op")
+			b.ReportMetric(float64(pop.Milliseconds())/float64(b.N), "pop-ms/op")
+		})
+	}
+}
+
+// BenchmarkOptions sweeps the settings of the optimized stack one at a time in
+// scenarios 4 and 5, where elimination can succeed.
+func BenchmarkOptions(b *testing.B) {
+	settings := []struct {
+		name string
+		opts []optimizationTreiber.Option
+	}{
+		{"default", nil},
+		{"without elimination", []optimizationTreiber.Option{optimizationTreiber.WithoutElimination()}},
+		{"capacity 1", []optimizationTreiber.Option{optimizationTreiber.WithEliminationCapacity(1)}},
+		{"capacity 4", []optimizationTreiber.Option{optimizationTreiber.WithEliminationCapacity(4)}},
+		{"capacity 32", []optimizationTreiber.Option{optimizationTreiber.WithEliminationCapacity(32)}},
+		{"wait steps 100", []optimizationTreiber.Option{optimizationTreiber.WithWaitSteps(100)}},
+		{"wait steps 10000", []optimizationTreiber.Option{optimizationTreiber.WithWaitSteps(10_000)}},
+		{"exponential backoff", []optimizationTreiber.Option{
+			optimizationTreiber.WithBackoff(optimizationTreiber.NewExponentialBackoff(0, 0)),
+		}},
+		{"time backoff", []optimizationTreiber.Option{
+			optimizationTreiber.WithBackoff(optimizationTreiber.NewTimeBackoff(0, 0)),
+		}},
+		{"exponential backoff without elimination", []optimizationTreiber.Option{
+			optimizationTreiber.WithoutElimination(),
+			optimizationTreiber.WithBackoff(optimizationTreiber.NewExponentialBackoff(0, 0)),
+		}},
+		{"time backoff without elimination", []optimizationTreiber.Option{
+			optimizationTreiber.WithoutElimination(),
+			optimizationTreiber.WithBackoff(optimizationTreiber.NewTimeBackoff(0, 0)),
+		}},
+	}
+	for _, setting := range settings {
+		b.Run(setting.name+" push and pop in row", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				optimizeTreiberStack := optimizationTreiber.CreateBackoffTreiberStack[int](setting.opts...)
+				PushAndPopInRow(&optimizeTreiberStack)
+			}
+		})
+
+		b.Run(setting.name+" random", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				optimizeTreiberStack := optimizationTreiber.CreateBackoffTreiberStack[int](setting.opts...)
+				PushPopConcurentRand(&optimizeTreiberStack)
+			}
+		})
+	}
+}
+
+// handOff runs goroutineCount pairs of a sender and a receiver passing
+// countElem values in total.
+func handOff(goroutineCount int, send func(int), receive func()) {
+	wg := sync.WaitGroup{}
+	wg.Add(2 * goroutineCount)
+	for i := 0; i < goroutineCount; i++ {
+		go func() {
+			defer wg.Done()
+			for j := 0; j < countElem/goroutineCount; j++ {
+				send(j)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < countElem/goroutineCount; j++ {
+				receive()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func BenchmarkSynchronousQueue(b *testing.B) {
+	for _, goroutineCount := range []int{1, 10, 100} {
+		b.Run(fmt.Sprintf("Unbuffered channel %d pairs", goroutineCount), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				ch := make(chan int)
+				handOff(goroutineCount, func(v int) { ch <- v }, func() { <-ch })
+			}
+		})
+
+		b.Run(fmt.Sprintf("Synchronous queue %d pairs", goroutineCount), func(b *testing.B) {
+			ctx := context.Background()
+			for i := 0; i < b.N; i++ {
+				queue := optimizationTreiber.NewSynchronousQueue[int]()
+				handOff(goroutineCount, func(v int) { queue.Put(ctx, v) }, func() { queue.Take(ctx) })
+			}
+		})
+	}
+}
+
+func BenchmarkQueues(b *testing.B) {
+	queueConstructors := []struct {
+		name      string
+		construct func() queues.Queue[int]
+	}{
+		{"MutexQueue", func() queues.Queue[int] { return Mutex.CreateMutexQueue[int]() }},
+		{"TwoLockQueue", func() queues.Queue[int] { return TwoLock.CreateTwoLockQueue[int]() }},
+		{"MSQueue", func() queues.Queue[int] { return MichaelScott.CreateMSQueue[int]() }},
+	}
+	scenarios := []struct {
+		name string
+		run  func(stack stacks.Stack[int])
+	}{
+		{"not concurrent", NonConcurrentPushAndPop},
+		{"little concurrent", littleConcurrent},
+		{"enqueue and dequeue in row", PushAndPopInRow},
+		{"random", PushPopConcurentRand},
+	}
+	for _, scenario := range scenarios {
+		for _, queue := range queueConstructors {
+			b.Run(queue.name+" "+scenario.name, func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					scenario.run(queues.AsStack(queue.construct()))
+				}
+			})
+		}
+	}
+}
+
+func fibonacci(w *forkjoin.Worker, n int) int {
+	if n < 2 {
+		return n
+	}
+	var left int
+	task := w.Fork(func(w *forkjoin.Worker) {
+		left = fibonacci(w, n-1)
+	})
+	right := fibonacci(w, n-2)
+	w.Join(task)
+	return left + right
+}
+
+// BenchmarkForkJoin computes fibonacci(25) forking both calls, which makes
+// about 250 thousand tiny tasks.
+func BenchmarkForkJoin(b *testing.B) {
+	pools := []struct {
+		name      string
+		construct func() *forkjoin.Pool
+	}{
+		{"Chase-Lev work-stealing pool", func() *forkjoin.Pool { return forkjoin.NewPool(0) }},
+		{"Shared TreiberStack pool", func() *forkjoin.Pool {
+			treiberStack := Treiber.CreateTreiberStack[*forkjoin.Task]()
+			return forkjoin.NewSharedStackPool(0, &treiberStack)
+		}},
+		{"Shared back-off elimination treiberStack pool", func() *forkjoin.Pool {
+			optimizeTreiberStack := optimizationTreiber.CreateBackoffTreiberStack[*forkjoin.Task]()
+			return forkjoin.NewSharedStackPool(0, &optimizeTreiberStack)
+		}},
+	}
+	for _, pool := range pools {
+		b.Run(pool.name, func(b *testing.B) {
+			p := pool.construct()
+			for i := 0; i < b.N; i++ {
+				p.Run(func(w *forkjoin.Worker) {
+					fibonacci(w, 25)
+				})
+			}
+		})
+	}
+}
+
+// BenchmarkRelaxed compares the k-LIFO stack for several relaxation bounds
+// with the strict TreiberStack in scenarios 4 and 5.
+func BenchmarkRelaxed(b *testing.B) {
+	b.Run("TreiberStack push and pop in row", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			treiberStack := Treiber.CreateTreiberStack[int]()
+			PushAndPopInRow(&treiberStack)
+		}
+	})
+
+	b.Run("TreiberStack random", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			treiberStack := Treiber.CreateTreiberStack[int]()
+			PushPopConcurentRand(&treiberStack)
+		}
+	})
+
+	for _, k := range []int{1, 3, 7, 15} {
+		b.Run(fmt.Sprintf("k-LIFO stack k=%d push and pop in row", k), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				relaxedStack := Relaxed.CreateKLIFOStack[int](k)
+				PushAndPopInRow(&relaxedStack)
+			}
+		})
+
+		b.Run(fmt.Sprintf("k-LIFO stack k=%d random", k), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				relaxedStack := Relaxed.CreateKLIFOStack[int](k)
+				PushPopConcurentRand(&relaxedStack)
+			}
+		})
+	}
+}
+
+// reportGC runs the benchmark loop and reports the GC pause time and the
+// number of collections per operation next to allocs/op.
+func reportGC(b *testing.B, f func()) {
+	b.ReportAllocs()
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	for i := 0; i < b.N; i++ {
+		f()
+	}
+	runtime.ReadMemStats(&after)
+	b.ReportMetric(float64(after.PauseTotalNs-before.PauseTotalNs)/float64(b.N), "gc-pause-ns/op")
+	b.ReportMetric(float64(after.NumGC-before.NumGC)/float64(b.N), "gc/op")
+}
+
+// BenchmarkRecycling compares the stacks with and without node recycling in
+// scenarios 1, 2 and 5.
+func BenchmarkRecycling(b *testing.B) {
+	constructors := []struct {
+		name      string
+		construct func() stacks.Stack[int]
+	}{
+		{"TreiberStack", func() stacks.Stack[int] {
+			treiberStack := Treiber.CreateTreiberStack[int]()
+			return &treiberStack
+		}},
+		{"Recycling TreiberStack", func() stacks.Stack[int] {
+			treiberStack := Treiber.CreateRecyclingTreiberStack[int]()
+			return &treiberStack
+		}},
+		{"Optimization back-off elimination treiberStack", func() stacks.Stack[int] {
+			optimizeTreiberStack := optimizationTreiber.CreateBackoffTreiberStack[int]()
+			return &optimizeTreiberStack
+		}},
+		{"Recycling optimization back-off elimination treiberStack", func() stacks.Stack[int] {
+			optimizeTreiberStack := optimizationTreiber.CreateBackoffTreiberStack[int](optimizationTreiber.WithNodeRecycling())
+			return &optimizeTreiberStack
+		}},
+	}
+	scenarios := []struct {
+		name string
+		run  func(stack stacks.Stack[int])
+	}{
+		{"not concurrent", NonConcurrentPushAndPop},
+		{"little concurrent", littleConcurrent},
+		{"random", PushPopConcurentRand},
+	}
+	for _, scenario := range scenarios {
+		for _, constructor := range constructors {
+			b.Run(constructor.name+" "+scenario.name, func(b *testing.B) {
+				// The stack lives across iterations, so that recycled nodes
+				// are reused by the next one.
+				stack := constructor.construct()
+				reportGC(b, func() {
+					scenario.run(stack)
+				})
+			})
+		}
+	}
+}
+
+func BenchmarkBlocking(b *testing.B) {
+	for _, goroutineCount := range []int{1, 10, 100} {
+		b.Run(fmt.Sprintf("Busy loop treiberStack %d pairs", goroutineCount), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				treiberStack := Treiber.CreateTreiberStack[int]()
+				handOff(goroutineCount, treiberStack.Push, func() {
+					for {
+						if _, err := treiberStack.Pop(); err == nil {
+							return
+						}
+					}
+				})
+			}
+		})
+
+		b.Run(fmt.Sprintf("PopWait treiberStack %d pairs", goroutineCount), func(b *testing.B) {
+			ctx := context.Background()
+			for i := 0; i < b.N; i++ {
+				treiberStack := Treiber.CreateTreiberStack[int]()
+				blockingStack := Blocking.CreateBlockingStack[int](&treiberStack)
+				handOff(goroutineCount, blockingStack.Push, func() { blockingStack.PopWait(ctx) })
+			}
+		})
+	}
+}
+
+// BenchmarkBounded compares allocations and time of the bounded stack with the
+// TreiberStack in scenarios 1, 2 and 4, which never hold more than countElem
+// elements.
+func BenchmarkBounded(b *testing.B) {
+	constructors := []struct {
+		name      string
+		construct func() stacks.Stack[int]
+	}{
+		{"TreiberStack", func() stacks.Stack[int] {
+			treiberStack := Treiber.CreateTreiberStack[int]()
+			return &treiberStack
+		}},
+		{"BoundedStack", func() stacks.Stack[int] {
+			boundedStack := Bounded.CreateBoundedStack[int](countElem)
+			return &boundedStack
+		}},
+	}
+	scenarios := []struct {
+		name string
+		run  func(stack stacks.Stack[int])
+	}{
+		{"not concurrent", NonConcurrentPushAndPop},
+		{"little concurrent", littleConcurrent},
+		{"push and pop in row", PushAndPopInRow},
+	}
+	for _, scenario := range scenarios {
+		for _, constructor := range constructors {
+			b.Run(constructor.name+" "+scenario.name, func(b *testing.B) {
+				// The array is allocated once, not in every iteration.
+				stack := constructor.construct()
+				reportGC(b, func() {
+					scenario.run(stack)
+				})
+			})
+		}
+	}
+}
+
+// batches pushes and pops countElem elements on 100 goroutines in batches
+// of batchSize.
+func batches(stack stacks.BatchStack[int], batchSize int) {
+	goroutineCount := 100
+	wg := sync.WaitGroup{}
+	wg.Add(goroutineCount)
+	for i := 0; i < goroutineCount; i++ {
+		go func() {
+			defer wg.Done()
+			batch := make([]int, batchSize)
+			for j := 0; j < countElem/goroutineCount/batchSize; j++ {
+				stack.PushAll(batch...)
+				stack.PopN(batchSize)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// BenchmarkBatch compares batches of one element, one CAS per element as in
+// Push and Pop, with larger ones.
+func BenchmarkBatch(b *testing.B) {
+	for _, batchSize := range []int{1, 16, 256} {
+		b.Run(fmt.Sprintf("TreiberStack batch %d", batchSize), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				treiberStack := Treiber.CreateTreiberStack[int]()
+				batches(&treiberStack, batchSize)
+			}
+		})
+
+		b.Run(fmt.Sprintf("Optimization back-off elimination treiberStack batch %d", batchSize), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				optimizeTreiberStack := optimizationTreiber.CreateBackoffTreiberStack[int]()
+				batches(&optimizeTreiberStack, batchSize)
+			}
+		})
+	}
+}
+
+// BenchmarkCounter compares the striped counter of the stack sizes with one
+// atomic counter under parallel updates, and the two sizes of a stack of
+// countElem elements.
+func BenchmarkCounter(b *testing.B) {
+	b.Run("Single atomic counter", func(b *testing.B) {
+		var single atomic.Int64
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				single.Add(1)
+			}
+		})
+	})
+
+	b.Run("Striped counter", func(b *testing.B) {
+		var striped counter.Striped
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				striped.Add(1)
+			}
+		})
+	})
+
+	treiberStack := Treiber.CreateTreiberStack[int]()
+	for j := 0; j < countElem; j++ {
+		treiberStack.Push(j)
+	}
+	b.Run("TreiberStack Size", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			treiberStack.Size()
+		}
+	})
+
+	b.Run("TreiberStack ExactSize", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			treiberStack.ExactSize()
+		}
+	})
 }
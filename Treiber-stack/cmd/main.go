@@ -0,0 +1,116 @@
+// Command cmd runs the stack scenarios of README.md and prints their results
+// as Markdown tables in the format of the README.
+//
+//	go run ./cmd -scenarios 1,2,5,mixed -goroutines 100 -ops 1000000 -repeats 5
+package main
+
+import (
+	"Treiber-stack/stacks"
+	"Treiber-stack/stacks/FlatCombining"
+	"Treiber-stack/stacks/Simple"
+	"Treiber-stack/stacks/Treiber"
+	"Treiber-stack/stacks/optimizationTreiber"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+type namedStack struct {
+	name   string
+	title  string
+	create func() stacks.Stack[int]
+}
+
+var allStacks = []namedStack{
+	{"simple", "Simple stack", func() stacks.Stack[int] {
+		stack := Simple.CreateSimpleStack[int]()
+		return &stack
+	}},
+	{"treiber", "Treiber stack", func() stacks.Stack[int] {
+		stack := Treiber.CreateTreiberStack[int]()
+		return &stack
+	}},
+	{"optimized", "Treiber optimization", func() stacks.Stack[int] {
+		stack := optimizationTreiber.CreateBackoffTreiberStack[int]()
+		return &stack
+	}},
+	{"flat-combining", "Flat combining", func() stacks.Stack[int] {
+		stack := FlatCombining.CreateFlatCombiningStack[int]()
+		return &stack
+	}},
+}
+
+type config struct {
+	scenarios  []scenario
+	stacks     []namedStack
+	goroutines int
+	ops        int
+	pushRatio  float64
+	repeats    int
+}
+
+func main() {
+	cfg, err := parseFlags(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "cmd:", err)
+		os.Exit(2)
+	}
+
+	for _, sc := range cfg.scenarios {
+		var rows []row
+		for _, stack := range cfg.stacks {
+			// The simple stack is not safe for concurrent use.
+			if sc.concurrent && stack.name == "simple" {
+				continue
+			}
+			rows = append(rows, measure(sc, stack, cfg))
+		}
+		printTable(os.Stdout, sc, rows)
+	}
+}
+
+func parseFlags(args []string) (cfg config, err error) {
+	var scenarioNames, stackNames string
+	fs := flag.NewFlagSet("cmd", flag.ContinueOnError)
+	fs.StringVar(&scenarioNames, "scenarios", "1,2,3,4,5", "comma-separated scenarios: "+scenarioList())
+	fs.StringVar(&stackNames, "stacks", "simple,treiber,optimized,flat-combining", "comma-separated stacks; the first one is the baseline of the speedup")
+	fs.IntVar(&cfg.goroutines, "goroutines", 100, "number of goroutines, scenario 3 starts one per operation")
+	fs.IntVar(&cfg.ops, "ops", 1_000_000, "number of pushes (and of pops) in a run")
+	fs.Float64Var(&cfg.pushRatio, "push-ratio", 0.5, "share of pushes in mixed, share of producers in producer-consumer")
+	fs.IntVar(&cfg.repeats, "repeats", 5, "runs of every scenario per stack")
+	if err = fs.Parse(args); err != nil {
+		return
+	}
+
+	switch {
+	case cfg.goroutines < 1:
+		return cfg, errors.New("-goroutines must be positive")
+	case cfg.ops < 1:
+		return cfg, errors.New("-ops must be positive")
+	case cfg.pushRatio < 0 || cfg.pushRatio > 1:
+		return cfg, errors.New("-push-ratio must be in [0, 1]")
+	case cfg.repeats < 1:
+		return cfg, errors.New("-repeats must be positive")
+	}
+
+	for _, name := range strings.Split(scenarioNames, ",") {
+		sc, ok := findScenario(name)
+		if !ok {
+			return cfg, fmt.Errorf("unknown scenario %q", name)
+		}
+		cfg.scenarios = append(cfg.scenarios, sc)
+	}
+	for _, name := range strings.Split(stackNames, ",") {
+		i := 0
+		for i < len(allStacks) && allStacks[i].name != name {
+			i++
+		}
+		if i == len(allStacks) {
+			return cfg, fmt.Errorf("unknown stack %q", name)
+		}
+		cfg.stacks = append(cfg.stacks, allStacks[i])
+	}
+	return cfg, nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"Treiber-stack/stacks"
+	"math/rand"
+	"strings"
+	"sync"
+)
+
+type scenario struct {
+	name       string
+	title      string
+	concurrent bool
+	run        func(stack stacks.Stack[int], cfg config)
+}
+
+// scenarios 1-5 are the scenarios of README.md, the others are new.
+var scenarios = []scenario{
+	{"1", "NonConcurrent", false, nonConcurrent},
+	{"2", "LittleConcurrent", true, littleConcurrent},
+	{"3", "AllConcurrent", true, allConcurrent},
+	{"4", "PushAndPopInRow", true, pushAndPopInRow},
+	{"5", "PushAndPopRandom", true, pushAndPopRandom},
+	{"mixed", "MixedRandom", true, mixedRandom},
+	{"producer-consumer", "ProducerConsumer", true, producerConsumer},
+}
+
+func findScenario(name string) (scenario, bool) {
+	for _, sc := range scenarios {
+		if sc.name == name {
+			return sc, true
+		}
+	}
+	return scenario{}, false
+}
+
+func scenarioList() string {
+	names := make([]string, len(scenarios))
+	for i, sc := range scenarios {
+		names[i] = sc.name
+	}
+	return strings.Join(names, ", ")
+}
+
+// share returns the number of operations of goroutine g when ops operations
+// are split between goroutines.
+func share(ops, goroutines, g int) int {
+	n := ops / goroutines
+	if g < ops%goroutines {
+		n++
+	}
+	return n
+}
+
+// nonConcurrent makes all pushes and then all pops in one goroutine.
+func nonConcurrent(stack stacks.Stack[int], cfg config) {
+	for j := 0; j < cfg.ops; j++ {
+		stack.Push(j)
+	}
+	for j := 0; j < cfg.ops; j++ {
+		stack.Pop()
+	}
+}
+
+// parallel runs f on every goroutine and waits for them.
+func parallel(goroutines int, f func(g int)) {
+	wg := sync.WaitGroup{}
+	wg.Add(goroutines)
+	for g := 0; g < goroutines; g++ {
+		go func(g int) {
+			defer wg.Done()
+			f(g)
+		}(g)
+	}
+	wg.Wait()
+}
+
+// littleConcurrent makes the pushes on all goroutines, waits for them and
+// then makes the pops the same way.
+func littleConcurrent(stack stacks.Stack[int], cfg config) {
+	parallel(cfg.goroutines, func(g int) {
+		for j := share(cfg.ops, cfg.goroutines, g); j > 0; j-- {
+			stack.Push(j)
+		}
+	})
+	parallel(cfg.goroutines, func(g int) {
+		for j := share(cfg.ops, cfg.goroutines, g); j > 0; j-- {
+			stack.Pop()
+		}
+	})
+}
+
+// allConcurrent is littleConcurrent with a goroutine per operation.
+func allConcurrent(stack stacks.Stack[int], cfg config) {
+	parallel(cfg.ops, func(g int) {
+		stack.Push(g)
+	})
+	parallel(cfg.ops, func(int) {
+		stack.Pop()
+	})
+}
+
+// pushAndPopInRow makes every goroutine alternate pushes and pops.
+func pushAndPopInRow(stack stacks.Stack[int], cfg config) {
+	parallel(cfg.goroutines, func(g int) {
+		for j := share(cfg.ops, cfg.goroutines, g); j > 0; j-- {
+			stack.Push(j)
+			stack.Pop()
+		}
+	})
+}
+
+// pushAndPopRandom runs pushing and popping goroutines at the same time, so
+// the order of the operations is up to the scheduler.
+func pushAndPopRandom(stack stacks.Stack[int], cfg config) {
+	parallel(2*cfg.goroutines, func(g int) {
+		for j := share(cfg.ops, cfg.goroutines, g/2); j > 0; j-- {
+			if g%2 == 0 {
+				stack.Push(j)
+			} else {
+				stack.Pop()
+			}
+		}
+	})
+}
+
+// mixedRandom makes every goroutine choose a push with probability
+// pushRatio and a pop otherwise, 2*ops operations in total.
+func mixedRandom(stack stacks.Stack[int], cfg config) {
+	parallel(cfg.goroutines, func(g int) {
+		r := rand.New(rand.NewSource(int64(g)))
+		for j := share(2*cfg.ops, cfg.goroutines, g); j > 0; j-- {
+			if r.Float64() < cfg.pushRatio {
+				stack.Push(j)
+			} else {
+				stack.Pop()
+			}
+		}
+	})
+}
+
+// producerConsumer splits the goroutines into pushRatio producers and
+// consumers; each side makes ops operations.
+func producerConsumer(stack stacks.Stack[int], cfg config) {
+	// Keep at least one goroutine on each side if there are two.
+	producers := int(cfg.pushRatio * float64(cfg.goroutines))
+	producers = min(max(producers, 1), max(cfg.goroutines-1, 1))
+	consumers := cfg.goroutines - producers
+	parallel(cfg.goroutines, func(g int) {
+		if g < producers {
+			for j := share(cfg.ops, producers, g); j > 0; j-- {
+				stack.Push(j)
+			}
+			return
+		}
+		for j := share(cfg.ops, consumers, g-producers); j > 0; j-- {
+			stack.Pop()
+		}
+	})
+}
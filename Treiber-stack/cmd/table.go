@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"runtime"
+	"time"
+)
+
+type row struct {
+	title  string
+	mean   float64
+	stddev float64
+}
+
+// measure runs the scenario cfg.repeats times on a new stack every time and
+// returns the mean and the sample standard deviation of the run time in ns.
+func measure(sc scenario, stack namedStack, cfg config) row {
+	times := make([]float64, cfg.repeats)
+	for i := range times {
+		s := stack.create()
+		runtime.GC()
+		start := time.Now()
+		sc.run(s, cfg)
+		times[i] = float64(time.Since(start).Nanoseconds())
+	}
+
+	var sum float64
+	for _, t := range times {
+		sum += t
+	}
+	res := row{title: stack.title, mean: sum / float64(len(times))}
+	if len(times) > 1 {
+		var squares float64
+		for _, t := range times {
+			squares += (t - res.mean) * (t - res.mean)
+		}
+		res.stddev = math.Sqrt(squares / float64(len(times)-1))
+	}
+	return res
+}
+
+// printTable prints the rows like the tables of README.md, the speedup is
+// relative to the first row.
+func printTable(w io.Writer, sc scenario, rows []row) {
+	if len(rows) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "**Сценарий %s**\n\n", sc.name)
+	fmt.Fprintf(w, "| %-20s | Time for one iteration _(ns/op)_ | Standard deviation | acceleration percentages __(%%)__ | \n", sc.title)
+	fmt.Fprintln(w, "|----------------------|----------------------------------|--------------------|----------------------------------|")
+	for _, r := range rows {
+		speedup := rows[0].mean / r.mean * 100
+		fmt.Fprintf(w, "| %-20s | %-32.1f | %-18.1f | %-32.1f |\n", r.title, r.mean, r.stddev, speedup)
+	}
+	fmt.Fprintln(w, "\n---")
+	fmt.Fprintln(w)
+}
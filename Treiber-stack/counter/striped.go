@@ -0,0 +1,40 @@
+package counter
+
+import (
+	"math/rand"
+	"sync/atomic"
+)
+
+// stripes is the number of cells, enough for the goroutines of a few dozen
+// cores to rarely hit the same one.
+const stripes = 16
+
+// Striped is a counter spread over cells on separate cache lines, so that
+// concurrent Add calls do not contend on one word. Add picks a random cell,
+// there is no goroutine-local storage in Go. The zero value is an empty
+// counter.
+//
+// Load sums the cells one by one, so it is not a snapshot: under concurrent
+// Add calls it may see an increment of one cell and miss an earlier one of
+// another. It is exact without concurrent updates.
+type Striped struct {
+	cells [stripes]cell
+}
+
+type cell struct {
+	value atomic.Int64
+	// Pads the cell to a cache line of 64 bytes.
+	_ [56]byte
+}
+
+func (c *Striped) Add(delta int64) {
+	c.cells[rand.Intn(stripes)].value.Add(delta)
+}
+
+func (c *Striped) Load() int64 {
+	var sum int64
+	for i := range c.cells {
+		sum += c.cells[i].value.Load()
+	}
+	return sum
+}
@@ -0,0 +1,108 @@
+package ChaseLev
+
+import "sync/atomic"
+
+const initialLogSize = 5
+
+// ChaseLevDeque is the dynamic circular work-stealing deque of Chase and Lev.
+// One goroutine, the owner, calls PushBottom and PopBottom and works with the
+// bottom end like with a stack; any goroutine may call Steal to take from the
+// top. The owner synchronizes with thieves only when one element is left.
+//
+// The array grows when full and the old one is left to the thieves that still
+// read it. Elements are stored by pointer, so a thief never reads a slot that
+// is being written.
+type ChaseLevDeque[T any] struct {
+	top    atomic.Int64
+	bottom atomic.Int64
+	array  atomic.Pointer[circularArray[T]]
+}
+
+type circularArray[T any] struct {
+	mask  int64
+	items []atomic.Pointer[T]
+}
+
+func newCircularArray[T any](logSize uint) *circularArray[T] {
+	return &circularArray[T]{mask: 1<<logSize - 1, items: make([]atomic.Pointer[T], 1<<logSize)}
+}
+
+func (a *circularArray[T]) get(i int64) *T {
+	return a.items[i&a.mask].Load()
+}
+
+func (a *circularArray[T]) put(i int64, value *T) {
+	a.items[i&a.mask].Store(value)
+}
+
+// grow returns a copy of the elements from top to bottom in a twice larger
+// array.
+func (a *circularArray[T]) grow(top, bottom int64) *circularArray[T] {
+	newArr := &circularArray[T]{mask: 2*a.mask + 1, items: make([]atomic.Pointer[T], 2*len(a.items))}
+	for i := top; i < bottom; i++ {
+		newArr.put(i, a.get(i))
+	}
+	return newArr
+}
+
+// PushBottom adds the value to the bottom, only the owner may call it.
+func (deque *ChaseLevDeque[T]) PushBottom(val T) {
+	bottom, top := deque.bottom.Load(), deque.top.Load()
+	array := deque.array.Load()
+	if bottom-top > array.mask {
+		array = array.grow(top, bottom)
+		deque.array.Store(array)
+	}
+	array.put(bottom, &val)
+	deque.bottom.Store(bottom + 1)
+}
+
+// PopBottom removes the value at the bottom, only the owner may call it.
+func (deque *ChaseLevDeque[T]) PopBottom() (nilVar T, ok bool) {
+	bottom := deque.bottom.Load() - 1
+	array := deque.array.Load()
+	deque.bottom.Store(bottom)
+	top := deque.top.Load()
+	if bottom < top {
+		deque.bottom.Store(top)
+		return
+	}
+	value := array.get(bottom)
+	if bottom > top {
+		return *value, true
+	}
+	// The last element, race with the thieves for it.
+	won := deque.top.CompareAndSwap(top, top+1)
+	deque.bottom.Store(top + 1)
+	if !won {
+		return
+	}
+	return *value, true
+}
+
+// Steal removes the value at the top. It fails if the deque is empty or if
+// another goroutine took the value first.
+func (deque *ChaseLevDeque[T]) Steal() (nilVar T, ok bool) {
+	top := deque.top.Load()
+	bottom := deque.bottom.Load()
+	if bottom <= top {
+		return
+	}
+	value := deque.array.Load().get(top)
+	if !deque.top.CompareAndSwap(top, top+1) {
+		return
+	}
+	return *value, true
+}
+
+// Size returns the number of elements, it is exact only when the deque is
+// not changing.
+func (deque *ChaseLevDeque[T]) Size() int {
+	return int(max(deque.bottom.Load()-deque.top.Load(), 0))
+}
+
+func CreateChaseLevDeque[T any]() *ChaseLevDeque[T] {
+	deque := &ChaseLevDeque[T]{}
+	deque.array.Store(newCircularArray[T](initialLogSize))
+	return deque
+}
@@ -0,0 +1,139 @@
+package forkjoin
+
+import (
+	"Treiber-stack/deques/ChaseLev"
+	"Treiber-stack/stacks"
+	"math/rand"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// Task is a forked computation, its results are passed through the closure
+// and may be read after Join returns.
+type Task struct {
+	run  func(w *Worker)
+	done atomic.Bool
+}
+
+// scheduler keeps the tasks that are ready to run.
+type scheduler interface {
+	// push is called by the worker that forks the task.
+	push(worker int, task *Task)
+	// pop returns a task for the worker, if there is one.
+	pop(worker int) (*Task, bool)
+}
+
+// Pool is a fork-join pool. A worker that waits for a task runs other ready
+// tasks meanwhile, so recursive computations never block a worker.
+type Pool struct {
+	workers int
+	sched   scheduler
+}
+
+// Worker is the goroutine that runs a task. Tasks fork and join through it.
+type Worker struct {
+	id   int
+	pool *Pool
+}
+
+// NewPool returns a work-stealing pool: every worker keeps its forks in its
+// own Chase-Lev deque and steals from the top of the others' deques when its
+// own deque is empty. Non-positive workers means GOMAXPROCS.
+func NewPool(workers int) *Pool {
+	workers = workersCount(workers)
+	deques := make([]*ChaseLev.ChaseLevDeque[*Task], workers)
+	for i := range deques {
+		deques[i] = ChaseLev.CreateChaseLevDeque[*Task]()
+	}
+	return &Pool{workers: workers, sched: &stealingScheduler{deques: deques}}
+}
+
+// NewSharedStackPool returns a pool whose workers share one concurrent
+// stack of tasks. Non-positive workers means GOMAXPROCS.
+func NewSharedStackPool(workers int, stack stacks.Stack[*Task]) *Pool {
+	return &Pool{workers: workersCount(workers), sched: &sharedScheduler{stack: stack}}
+}
+
+func workersCount(workers int) int {
+	if workers <= 0 {
+		return runtime.GOMAXPROCS(0)
+	}
+	return workers
+}
+
+// Run runs f on the pool and returns when it and everything it joined are
+// done. Forked tasks that are never joined may not have run by then. Run must
+// not be called concurrently on the same pool.
+func (p *Pool) Run(f func(w *Worker)) {
+	root := &Task{run: f}
+	p.sched.push(0, root)
+
+	wg := sync.WaitGroup{}
+	wg.Add(p.workers)
+	for i := 0; i < p.workers; i++ {
+		go func(i int) {
+			defer wg.Done()
+			worker := &Worker{id: i, pool: p}
+			worker.Join(root)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// Fork makes f ready to run on any worker.
+func (w *Worker) Fork(f func(w *Worker)) *Task {
+	task := &Task{run: f}
+	w.pool.sched.push(w.id, task)
+	return task
+}
+
+// Join waits for the task, running ready tasks meanwhile.
+func (w *Worker) Join(task *Task) {
+	for !task.done.Load() {
+		if ready, ok := w.pool.sched.pop(w.id); ok {
+			ready.run(w)
+			ready.done.Store(true)
+		} else {
+			runtime.Gosched()
+		}
+	}
+}
+
+type stealingScheduler struct {
+	deques []*ChaseLev.ChaseLevDeque[*Task]
+}
+
+func (s *stealingScheduler) push(worker int, task *Task) {
+	s.deques[worker].PushBottom(task)
+}
+
+func (s *stealingScheduler) pop(worker int) (*Task, bool) {
+	if task, ok := s.deques[worker].PopBottom(); ok {
+		return task, true
+	}
+	start := rand.Intn(len(s.deques))
+	for i := range s.deques {
+		victim := (start + i) % len(s.deques)
+		if victim == worker {
+			continue
+		}
+		if task, ok := s.deques[victim].Steal(); ok {
+			return task, true
+		}
+	}
+	return nil, false
+}
+
+type sharedScheduler struct {
+	stack stacks.Stack[*Task]
+}
+
+func (s *sharedScheduler) push(_ int, task *Task) {
+	s.stack.Push(task)
+}
+
+func (s *sharedScheduler) pop(int) (*Task, bool) {
+	task, err := s.stack.Pop()
+	return task, err == nil
+}
@@ -1,3 +1,7 @@
 module Treiber-stack
 
 go 1.21.5
+
+require lincheck v0.0.0
+
+replace lincheck => ../lincheck
@@ -0,0 +1,110 @@
+package linearizability
+
+import (
+	"Treiber-stack/stacks"
+	"lincheck"
+)
+
+type StackOp int
+
+const (
+	StackPush StackOp = iota
+	StackPop
+)
+
+type StackInput[T any] struct {
+	Op    StackOp
+	Value T
+}
+
+// StackOutput is the result of Pop; Push has none. Err is set if Pop found
+// the stack empty.
+type StackOutput[T any] struct {
+	Value T
+	Err   error
+}
+
+// StackState is an immutable LIFO stack, nil is the empty one.
+type StackState[T any] struct {
+	value T
+	next  *StackState[T]
+}
+
+// StackModel is the sequential specification of a LIFO stack.
+func StackModel[T comparable]() lincheck.Model[*StackState[T], StackInput[T], StackOutput[T]] {
+	return lincheck.Model[*StackState[T], StackInput[T], StackOutput[T]]{
+		Init: func() *StackState[T] {
+			return nil
+		},
+		Step: func(state *StackState[T], input StackInput[T], output StackOutput[T]) (bool, *StackState[T]) {
+			switch {
+			case input.Op == StackPush:
+				return true, &StackState[T]{value: input.Value, next: state}
+			case state == nil:
+				return output.Err != nil, state
+			default:
+				return output.Err == nil && output.Value == state.value, state.next
+			}
+		},
+		Equal: func(a, b *StackState[T]) bool {
+			for ; a != nil && b != nil; a, b = a.next, b.next {
+				if a == b {
+					return true
+				}
+				if a.value != b.value {
+					return false
+				}
+			}
+			return a == b
+		},
+	}
+}
+
+// StackRecorder records the operations of goroutines sharing a stack.
+type StackRecorder[T any] struct {
+	lincheck.Recorder[StackInput[T], StackOutput[T]]
+	stack stacks.Stack[T]
+}
+
+func NewStackRecorder[T any](stack stacks.Stack[T]) *StackRecorder[T] {
+	return &StackRecorder[T]{stack: stack}
+}
+
+// RecordedStack is the view of the stack for one goroutine. Push, Pop and
+// TryPop are recorded, the other methods are passed through.
+type RecordedStack[T any] struct {
+	stacks.Stack[T]
+	client *lincheck.Client[StackInput[T], StackOutput[T]]
+}
+
+// Client returns the view of the stack for a new goroutine.
+func (r *StackRecorder[T]) Client() *RecordedStack[T] {
+	return &RecordedStack[T]{Stack: r.stack, client: r.NewClient()}
+}
+
+func (s *RecordedStack[T]) Push(value T) {
+	s.client.Do(StackInput[T]{Op: StackPush, Value: value}, func() StackOutput[T] {
+		s.Stack.Push(value)
+		return StackOutput[T]{}
+	})
+}
+
+func (s *RecordedStack[T]) Pop() (T, error) {
+	output := s.client.Do(StackInput[T]{Op: StackPop}, func() StackOutput[T] {
+		value, err := s.Stack.Pop()
+		return StackOutput[T]{Value: value, Err: err}
+	})
+	return output.Value, output.Err
+}
+
+// TryPop is recorded as a Pop.
+func (s *RecordedStack[T]) TryPop() (T, bool) {
+	output := s.client.Do(StackInput[T]{Op: StackPop}, func() StackOutput[T] {
+		value, ok := s.Stack.TryPop()
+		if !ok {
+			return StackOutput[T]{Err: stacks.ErrEmpty}
+		}
+		return StackOutput[T]{Value: value}
+	})
+	return output.Value, output.Err == nil
+}
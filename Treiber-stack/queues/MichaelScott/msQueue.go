@@ -0,0 +1,88 @@
+package MichaelScott
+
+import (
+	"Treiber-stack/queues"
+	"sync/atomic"
+)
+
+// MSQueue is the lock-free queue of Michael and Scott. The head is a dummy
+// node, the tail may lag one node behind, and every operation that sees it
+// lagging helps to move it.
+type MSQueue[T any] struct {
+	head atomic.Pointer[MSNode[T]]
+	tail atomic.Pointer[MSNode[T]]
+}
+
+type MSNode[T any] struct {
+	value T
+	next  atomic.Pointer[MSNode[T]]
+}
+
+func (queue *MSQueue[T]) Enqueue(val T) {
+	newNode := &MSNode[T]{value: val}
+	for {
+		tail := queue.tail.Load()
+		next := tail.next.Load()
+		if tail != queue.tail.Load() {
+			continue
+		}
+		if next != nil {
+			queue.tail.CompareAndSwap(tail, next)
+			continue
+		}
+		if tail.next.CompareAndSwap(nil, newNode) {
+			queue.tail.CompareAndSwap(tail, newNode)
+			return
+		}
+	}
+}
+
+func (queue *MSQueue[T]) Dequeue() (nilVar T, Err error) {
+	for {
+		head, tail := queue.head.Load(), queue.tail.Load()
+		next := head.next.Load()
+		if head != queue.head.Load() {
+			continue
+		}
+		if next == nil {
+			return nilVar, queues.ErrEmpty
+		}
+		if head == tail {
+			queue.tail.CompareAndSwap(tail, next)
+			continue
+		}
+		if queue.head.CompareAndSwap(head, next) {
+			return next.value, nil
+		}
+	}
+}
+
+func (queue *MSQueue[T]) Peek() (nilVar T, ok bool) {
+	if queue == nil {
+		return
+	}
+	first := queue.head.Load().next.Load()
+	if first == nil {
+		return
+	}
+	return first.value, true
+}
+
+func (queue *MSQueue[T]) Size() int {
+	elemCounter := 0
+	if queue == nil {
+		return 0
+	}
+	for curr := queue.head.Load().next.Load(); curr != nil; curr = curr.next.Load() {
+		elemCounter++
+	}
+	return elemCounter
+}
+
+func CreateMSQueue[T any]() *MSQueue[T] {
+	queue := &MSQueue[T]{}
+	dummy := &MSNode[T]{}
+	queue.head.Store(dummy)
+	queue.tail.Store(dummy)
+	return queue
+}
@@ -0,0 +1,66 @@
+package Mutex
+
+import (
+	"Treiber-stack/queues"
+	"sync"
+)
+
+// MutexQueue is a linked queue guarded by one mutex.
+type MutexQueue[T any] struct {
+	mutex sync.Mutex
+	head  *Node[T]
+	tail  *Node[T]
+	size  int
+}
+
+type Node[T any] struct {
+	value T
+	next  *Node[T]
+}
+
+func (queue *MutexQueue[T]) Enqueue(val T) {
+	newNode := &Node[T]{value: val}
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+	if queue.tail == nil {
+		queue.head = newNode
+	} else {
+		queue.tail.next = newNode
+	}
+	queue.tail = newNode
+	queue.size++
+}
+
+func (queue *MutexQueue[T]) Dequeue() (nilVar T, Err error) {
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+	if queue.head == nil {
+		return nilVar, queues.ErrEmpty
+	}
+	first := queue.head
+	queue.head = first.next
+	if queue.head == nil {
+		queue.tail = nil
+	}
+	queue.size--
+	return first.value, nil
+}
+
+func (queue *MutexQueue[T]) Peek() (nilVar T, ok bool) {
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+	if queue.head == nil {
+		return
+	}
+	return queue.head.value, true
+}
+
+func (queue *MutexQueue[T]) Size() int {
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+	return queue.size
+}
+
+func CreateMutexQueue[T any]() *MutexQueue[T] {
+	return &MutexQueue[T]{}
+}
@@ -0,0 +1,72 @@
+package TwoLock
+
+import (
+	"Treiber-stack/queues"
+	"sync"
+	"sync/atomic"
+)
+
+// TwoLockQueue is the two-lock queue of Michael and Scott: the head and the
+// tail have their own locks, so Enqueue and Dequeue do not block each other.
+// The head is a dummy node, so they never touch the same node except through
+// its next link, which is atomic for this reason.
+type TwoLockQueue[T any] struct {
+	headLock sync.Mutex
+	tailLock sync.Mutex
+	head     *TLNode[T]
+	tail     *TLNode[T]
+}
+
+type TLNode[T any] struct {
+	value T
+	next  atomic.Pointer[TLNode[T]]
+}
+
+func (queue *TwoLockQueue[T]) Enqueue(val T) {
+	newNode := &TLNode[T]{value: val}
+	queue.tailLock.Lock()
+	defer queue.tailLock.Unlock()
+	queue.tail.next.Store(newNode)
+	queue.tail = newNode
+}
+
+func (queue *TwoLockQueue[T]) Dequeue() (nilVar T, Err error) {
+	queue.headLock.Lock()
+	defer queue.headLock.Unlock()
+	first := queue.head.next.Load()
+	if first == nil {
+		return nilVar, queues.ErrEmpty
+	}
+	queue.head = first
+	value := first.value
+	// The new dummy must not keep the value alive.
+	first.value = nilVar
+	return value, nil
+}
+
+func (queue *TwoLockQueue[T]) Peek() (nilVar T, ok bool) {
+	queue.headLock.Lock()
+	defer queue.headLock.Unlock()
+	first := queue.head.next.Load()
+	if first == nil {
+		return
+	}
+	return first.value, true
+}
+
+// Size counts the nodes under the head lock, concurrent enqueues may or may
+// not be counted.
+func (queue *TwoLockQueue[T]) Size() int {
+	queue.headLock.Lock()
+	defer queue.headLock.Unlock()
+	elemCounter := 0
+	for curr := queue.head.next.Load(); curr != nil; curr = curr.next.Load() {
+		elemCounter++
+	}
+	return elemCounter
+}
+
+func CreateTwoLockQueue[T any]() *TwoLockQueue[T] {
+	dummy := &TLNode[T]{}
+	return &TwoLockQueue[T]{head: dummy, tail: dummy}
+}
@@ -0,0 +1,34 @@
+package queues
+
+import "Treiber-stack/stacks"
+
+// AsStack runs a queue through code written for stacks, such as the stack
+// tests and benchmarks: Push enqueues and Pop dequeues, so the order is FIFO.
+func AsStack[T any](queue Queue[T]) stacks.Stack[T] {
+	return queueAsStack[T]{queue}
+}
+
+type queueAsStack[T any] struct {
+	Queue[T]
+}
+
+func (q queueAsStack[T]) Push(val T) {
+	q.Enqueue(val)
+}
+
+func (q queueAsStack[T]) Pop() (T, error) {
+	val, err := q.Dequeue()
+	if err == ErrEmpty {
+		err = stacks.ErrEmpty
+	}
+	return val, err
+}
+
+func (q queueAsStack[T]) TryPop() (T, bool) {
+	val, err := q.Dequeue()
+	return val, err == nil
+}
+
+func (q queueAsStack[T]) IsEmpty() bool {
+	return q.Size() == 0
+}
@@ -0,0 +1,17 @@
+package queues
+
+import "errors"
+
+// ErrEmpty is returned by Dequeue of an empty queue.
+var ErrEmpty = errors.New("queue is empty")
+
+type Queue[T any] interface {
+	Enqueue(T)
+	// Dequeue removes the first element, it returns ErrEmpty if there is
+	// none.
+	Dequeue() (T, error)
+	// Peek returns the first element without removing it, or false if the
+	// queue is empty.
+	Peek() (T, bool)
+	Size() int
+}
@@ -0,0 +1,100 @@
+package reclamation
+
+import (
+	"math/bits"
+	"sync/atomic"
+)
+
+// cell holds a node in a limbo list or in the pool. Cells are reused, so the
+// lists are Treiber stacks of cell references whose top is a word packing the
+// reference with a version that every CAS increments, like in
+// Bounded.BoundedStack. A cell that is popped and pushed again between Load
+// and CompareAndSwap of another goroutine does not cause ABA unless the 32-bit
+// version wraps around during that CAS attempt.
+type cell[N any] struct {
+	node *N
+	// next is the reference of the next cell, see pack.
+	next atomic.Uint32
+}
+
+// arena is a growable array of cells that never moves them: chunk i holds the
+// cells with references from 1<<i to 1<<(i+1)-1, reference 0 is no cell.
+type arena[N any] struct {
+	chunks [32]atomic.Pointer[[]cell[N]]
+	used   atomic.Uint32
+}
+
+// pack makes a list word of a cell reference and a version.
+func pack(ref, version uint32) uint64 {
+	return uint64(version)<<32 | uint64(ref)
+}
+
+func unpack(word uint64) (ref, version uint32) {
+	return uint32(word), uint32(word >> 32)
+}
+
+func (a *arena[N]) at(ref uint32) *cell[N] {
+	chunk := bits.Len32(ref) - 1
+	return &(*a.chunks[chunk].Load())[ref-1<<chunk]
+}
+
+// alloc returns the reference of a new cell. The goroutines that get the
+// first references of a chunk race to create it, one of them wins.
+func (a *arena[N]) alloc() uint32 {
+	ref := a.used.Add(1)
+	chunk := bits.Len32(ref) - 1
+	if a.chunks[chunk].Load() == nil {
+		cells := make([]cell[N], 1<<chunk)
+		a.chunks[chunk].CompareAndSwap(nil, &cells)
+	}
+	return ref
+}
+
+// push puts the chain of cells from first to last on top of the list.
+func (a *arena[N]) push(list *atomic.Uint64, first, last uint32) {
+	for {
+		word := list.Load()
+		top, version := unpack(word)
+		a.at(last).next.Store(top)
+		if list.CompareAndSwap(word, pack(first, version+1)) {
+			return
+		}
+	}
+}
+
+// pop takes the top cell of the list, it returns 0 if the list is empty.
+func (a *arena[N]) pop(list *atomic.Uint64) uint32 {
+	for {
+		word := list.Load()
+		top, version := unpack(word)
+		if top == 0 {
+			return 0
+		}
+		if list.CompareAndSwap(word, pack(a.at(top).next.Load(), version+1)) {
+			return top
+		}
+	}
+}
+
+// take empties the list and returns its first cell.
+func (a *arena[N]) take(list *atomic.Uint64) uint32 {
+	for {
+		word := list.Load()
+		top, version := unpack(word)
+		if top == 0 {
+			return 0
+		}
+		if list.CompareAndSwap(word, pack(0, version+1)) {
+			return top
+		}
+	}
+}
+
+// chain returns the last cell and the length of a chain taken from a list.
+func (a *arena[N]) chain(first uint32) (last uint32, count uint64) {
+	last, count = first, 1
+	for next := a.at(last).next.Load(); next != 0; next = a.at(last).next.Load() {
+		last, count = next, count+1
+	}
+	return last, count
+}
@@ -0,0 +1,186 @@
+package reclamation
+
+import (
+	"math/rand"
+	"runtime"
+	"sync/atomic"
+)
+
+// advanceEvery is how many retirements pass between attempts to advance the
+// epoch.
+const advanceEvery = 128
+
+// EBR is epoch-based reclamation of nodes of type N with a pool of reclaimed
+// nodes. A goroutine that reads shared nodes enters a critical section and
+// announces the global epoch in a slot; a node removed from the structure is
+// retired into the list of that epoch. The epoch advances only when every
+// active goroutine has announced the current one, so nodes retired two
+// advances ago cannot be referenced by anyone and go to the pool.
+//
+// Go has no goroutine-local storage, so the announcements are slots taken for
+// the duration of a critical section. The registry of slots grows when all of
+// them are taken, so Enter never waits. The limbo lists and the pool are
+// lock-free stacks of cells, see cell, and the pool is not a sync.Pool, which
+// drops its content on every GC: it keeps all reclaimed nodes, at most as
+// many as the structure once held.
+type EBR[N any] struct {
+	epoch   atomic.Uint64
+	slots   atomic.Pointer[[]*slot]
+	limbo   [3]atomic.Uint64
+	retires atomic.Uint64
+	// free holds the cells of the pool, spare the cells without a node.
+	free  atomic.Uint64
+	spare atomic.Uint64
+	cells arena[N]
+
+	retired   atomic.Uint64
+	reclaimed atomic.Uint64
+}
+
+// slot is an announcement, 0 while it is free.
+type slot struct {
+	state atomic.Uint64
+}
+
+// Guard is a critical section, nodes read inside it are not reused until it
+// ends.
+type Guard struct {
+	slot  *slot
+	epoch uint64
+}
+
+// NewEBR returns a domain that starts with slots announcement slots and adds
+// more when more goroutines are in critical sections at once. Non-positive
+// slots means four per GOMAXPROCS.
+func NewEBR[N any](slots int) *EBR[N] {
+	if slots <= 0 {
+		slots = 4 * runtime.GOMAXPROCS(0)
+	}
+	registry := make([]*slot, slots)
+	for i := range registry {
+		registry[i] = &slot{}
+	}
+	d := &EBR[N]{}
+	d.slots.Store(&registry)
+	return d
+}
+
+// Enter starts a critical section. If all slots are taken, it adds one.
+func (d *EBR[N]) Enter() Guard {
+	registry := *d.slots.Load()
+	start := rand.Intn(len(registry))
+	for i := range registry {
+		s := registry[(start+i)%len(registry)]
+		epoch := d.epoch.Load()
+		if s.state.CompareAndSwap(0, announce(epoch)) {
+			return d.validate(s, epoch)
+		}
+	}
+
+	s := &slot{}
+	epoch := d.epoch.Load()
+	s.state.Store(announce(epoch))
+	for {
+		old := d.slots.Load()
+		grown := append((*old)[:len(*old):len(*old)], s)
+		if d.slots.CompareAndSwap(old, &grown) {
+			return d.validate(s, epoch)
+		}
+	}
+}
+
+// validate returns the guard of the slot s announcing epoch. The epoch may
+// have advanced before the announcement was seen, then it is announced again.
+func (d *EBR[N]) validate(s *slot, epoch uint64) Guard {
+	for now := d.epoch.Load(); now != epoch; now = d.epoch.Load() {
+		epoch = now
+		s.state.Store(announce(epoch))
+	}
+	return Guard{slot: s, epoch: epoch}
+}
+
+// announce marks an occupied slot, so that epoch 0 differs from a free slot.
+func announce(epoch uint64) uint64 {
+	return epoch<<1 | 1
+}
+
+// Exit ends the critical section.
+func (d *EBR[N]) Exit(g Guard) {
+	g.slot.state.Store(0)
+}
+
+// hold returns a cell holding the node, reusing a spare one if there is any.
+func (d *EBR[N]) hold(node *N) uint32 {
+	ref := d.cells.pop(&d.spare)
+	if ref == 0 {
+		ref = d.cells.alloc()
+	}
+	d.cells.at(ref).node = node
+	return ref
+}
+
+// Retire hands a node removed from the structure inside the critical
+// section g over to the domain.
+func (d *EBR[N]) Retire(g Guard, node *N) {
+	ref := d.hold(node)
+	d.cells.push(&d.limbo[g.epoch%3], ref, ref)
+	d.retired.Add(1)
+
+	if d.retires.Add(1)%advanceEvery == 0 {
+		d.TryAdvance()
+	}
+}
+
+// TryAdvance advances the epoch if every active critical section has seen the
+// current one and moves the nodes retired two epochs before to the pool.
+//
+// The list of epoch-2 is the list of the next epoch, so it is taken before the
+// epoch advances. If another goroutine advances the epoch first, the taken
+// nodes may include ones retired in the next epoch, and they are put back.
+func (d *EBR[N]) TryAdvance() bool {
+	epoch := d.epoch.Load()
+	for _, s := range *d.slots.Load() {
+		if state := s.state.Load(); state != 0 && state != announce(epoch) {
+			return false
+		}
+	}
+
+	limbo := &d.limbo[(epoch+1)%3]
+	first := d.cells.take(limbo)
+	if first == 0 {
+		return d.epoch.CompareAndSwap(epoch, epoch+1)
+	}
+	last, count := d.cells.chain(first)
+	if !d.epoch.CompareAndSwap(epoch, epoch+1) {
+		d.cells.push(limbo, first, last)
+		return false
+	}
+	d.cells.push(&d.free, first, last)
+	d.reclaimed.Add(count)
+	return true
+}
+
+// Alloc returns a reclaimed node, or a new one if the pool is empty. A reused
+// node keeps the fields it had when it was retired.
+func (d *EBR[N]) Alloc() *N {
+	ref := d.cells.pop(&d.free)
+	if ref == 0 {
+		return new(N)
+	}
+	c := d.cells.at(ref)
+	node := c.node
+	c.node = nil
+	d.cells.push(&d.spare, ref, ref)
+	return node
+}
+
+// Release returns a node that was never shared to the pool at once.
+func (d *EBR[N]) Release(node *N) {
+	ref := d.hold(node)
+	d.cells.push(&d.free, ref, ref)
+}
+
+// Stats returns the number of retired nodes and of those moved to the pool.
+func (d *EBR[N]) Stats() (retired, reclaimed uint64) {
+	return d.retired.Load(), d.reclaimed.Load()
+}
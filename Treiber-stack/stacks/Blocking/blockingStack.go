@@ -0,0 +1,134 @@
+package Blocking
+
+import (
+	"Treiber-stack/stacks"
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrClosed is returned by PopWait of a closed and empty stack and by PushErr
+// of a closed stack.
+var ErrClosed = errors.New("stack is closed")
+
+// BlockingStack adds PopWait and Close to a concurrent stack. Push and the
+// other methods go straight to the stack, and PopWait takes the element at
+// once if there is one, so the lock-free fast path stays intact.
+//
+// A PopWait that found the stack empty parks on a channel. Push closes it and
+// wakes all parked goroutines when there are any, the ones that lose the race
+// for the element park again. A waiter is counted before its last attempt to
+// pop, and Push checks the counter after pushing, so a wake-up is never lost.
+//
+// Push checks closed and pushes holding the read lock of closeMutex, which
+// Close takes for writing, so a Push either lands before Close returns or
+// sees the stack closed. Pushes share the read lock and do not wait for each
+// other, but Close waits for the pushes in progress, a Push into a full
+// wrapped BoundedStack among them.
+type BlockingStack[T any] struct {
+	stack      stacks.Stack[T]
+	waiters    atomic.Int64
+	closed     atomic.Bool
+	closeMutex sync.RWMutex
+	mutex      sync.Mutex
+	wake       chan struct{}
+}
+
+// Push drops the element if the stack is closed, use PushErr to find out.
+func (stack *BlockingStack[T]) Push(val T) {
+	_ = stack.PushErr(val)
+}
+
+// PushErr pushes the element, or returns ErrClosed if the stack is closed.
+func (stack *BlockingStack[T]) PushErr(val T) error {
+	stack.closeMutex.RLock()
+	if stack.closed.Load() {
+		stack.closeMutex.RUnlock()
+		return ErrClosed
+	}
+	stack.stack.Push(val)
+	stack.closeMutex.RUnlock()
+
+	if stack.waiters.Load() > 0 {
+		stack.broadcast()
+	}
+	return nil
+}
+
+func (stack *BlockingStack[T]) broadcast() {
+	stack.mutex.Lock()
+	close(stack.wake)
+	stack.wake = make(chan struct{})
+	stack.mutex.Unlock()
+}
+
+// PopWait pops the top element, waiting for one if the stack is empty. Like a
+// receive from a channel, it returns the elements left in a closed stack and
+// then ErrClosed. It returns ctx.Err() if ctx is done first.
+func (stack *BlockingStack[T]) PopWait(ctx context.Context) (nilVar T, Err error) {
+	if val, ok := stack.stack.TryPop(); ok {
+		return val, nil
+	}
+
+	stack.waiters.Add(1)
+	defer stack.waiters.Add(-1)
+	for {
+		stack.mutex.Lock()
+		wake := stack.wake
+		stack.mutex.Unlock()
+
+		if val, ok := stack.stack.TryPop(); ok {
+			return val, nil
+		}
+		if stack.closed.Load() {
+			// An element pushed right before Close may still be there.
+			if val, ok := stack.stack.TryPop(); ok {
+				return val, nil
+			}
+			return nilVar, ErrClosed
+		}
+
+		select {
+		case <-wake:
+		case <-ctx.Done():
+			return nilVar, ctx.Err()
+		}
+	}
+}
+
+// Close wakes all waiting PopWait calls. Elements that are left can still be
+// popped, later pushes are rejected. Close of a closed stack does nothing.
+func (stack *BlockingStack[T]) Close() {
+	stack.closeMutex.Lock()
+	wasClosed := stack.closed.Swap(true)
+	stack.closeMutex.Unlock()
+	if !wasClosed {
+		stack.broadcast()
+	}
+}
+
+func (stack *BlockingStack[T]) Pop() (T, error) {
+	return stack.stack.Pop()
+}
+
+func (stack *BlockingStack[T]) TryPop() (T, bool) {
+	return stack.stack.TryPop()
+}
+
+func (stack *BlockingStack[T]) Peek() (T, bool) {
+	return stack.stack.Peek()
+}
+
+func (stack *BlockingStack[T]) IsEmpty() bool {
+	return stack.stack.IsEmpty()
+}
+
+func (stack *BlockingStack[T]) Size() int {
+	return stack.stack.Size()
+}
+
+// CreateBlockingStack wraps a stack that is safe for concurrent use.
+func CreateBlockingStack[T any](stack stacks.Stack[T]) BlockingStack[T] {
+	return BlockingStack[T]{stack: stack, wake: make(chan struct{})}
+}
@@ -0,0 +1,229 @@
+package Bounded
+
+import (
+	"Treiber-stack/counter"
+	"Treiber-stack/stacks"
+	"errors"
+	"math"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrFull is returned by PushErr on a full stack.
+var ErrFull = errors.New("stack is full")
+
+// BoundedStack is a lock-free stack of at most Cap elements that does not
+// allocate after it is created. The elements live in a fixed array of nodes
+// linked by indices into two Treiber stacks: the stack itself and a free
+// list. The top of each is a word packing the index of its top node with a
+// version that every CAS increments, so a node that is popped and pushed
+// again between Load and CompareAndSwap of another goroutine does not cause
+// ABA. The version is 32 bits, it would have to wrap around during one CAS
+// attempt for ABA to happen.
+//
+// A node taken by a Pop that has not returned yet still counts against the
+// capacity, so TryPush may report a full stack for that short time.
+type BoundedStack[T any] struct {
+	nodes []node[T]
+	head  atomic.Uint64
+	free  atomic.Uint64
+	// used counts nodes taken from the array, the free list has the ones
+	// that were popped.
+	used atomic.Uint32
+	size counter.Striped
+	// waiters counts Push calls waiting on space for a free node, Pop takes
+	// the lock of space only when there are some.
+	waiters atomic.Int32
+	space   *sync.Cond
+}
+
+type node[T any] struct {
+	value T
+	// next is the reference of the next node, see pack.
+	next atomic.Uint32
+	// readers counts Peek calls that may read value, the owner of the node
+	// waits for them before writing it.
+	readers atomic.Int32
+}
+
+// pack makes a word of a node reference, its index plus one with 0 for no
+// node, and a version.
+func pack(ref, version uint32) uint64 {
+	return uint64(version)<<32 | uint64(ref)
+}
+
+func unpack(word uint64) (ref, version uint32) {
+	return uint32(word), uint32(word >> 32)
+}
+
+func (stack *BoundedStack[T]) node(ref uint32) *node[T] {
+	return &stack.nodes[ref-1]
+}
+
+// link pushes the node onto the list.
+func (stack *BoundedStack[T]) link(list *atomic.Uint64, ref uint32) {
+	n := stack.node(ref)
+	for {
+		word := list.Load()
+		top, version := unpack(word)
+		n.next.Store(top)
+		if list.CompareAndSwap(word, pack(ref, version+1)) {
+			return
+		}
+	}
+}
+
+// unlink pops a node from the list, it returns 0 if the list is empty.
+func (stack *BoundedStack[T]) unlink(list *atomic.Uint64) uint32 {
+	for {
+		word := list.Load()
+		top, version := unpack(word)
+		if top == 0 {
+			return 0
+		}
+		if list.CompareAndSwap(word, pack(stack.node(top).next.Load(), version+1)) {
+			return top
+		}
+	}
+}
+
+// acquire returns a node that is in neither list, or 0 if there is none.
+func (stack *BoundedStack[T]) acquire() uint32 {
+	if ref := stack.unlink(&stack.free); ref != 0 {
+		return ref
+	}
+	for {
+		used := stack.used.Load()
+		if int(used) == len(stack.nodes) {
+			return 0
+		}
+		if stack.used.CompareAndSwap(used, used+1) {
+			return used + 1
+		}
+	}
+}
+
+// exclusive waits until no Peek reads the value of the node.
+func (n *node[T]) exclusive() {
+	for n.readers.Load() != 0 {
+		runtime.Gosched()
+	}
+}
+
+// TryPush pushes the element, or returns false if the stack is full.
+func (stack *BoundedStack[T]) TryPush(val T) bool {
+	ref := stack.acquire()
+	if ref == 0 {
+		return false
+	}
+	n := stack.node(ref)
+	n.exclusive()
+	n.value = val
+	stack.link(&stack.head, ref)
+	stack.size.Add(1)
+	return true
+}
+
+// PushErr pushes the element, or returns ErrFull if the stack is full.
+func (stack *BoundedStack[T]) PushErr(val T) error {
+	if !stack.TryPush(val) {
+		return ErrFull
+	}
+	return nil
+}
+
+// Push waits until the stack has a free place, so that a BoundedStack used
+// as a stacks.Stack applies backpressure instead of failing. It sleeps on a
+// condition that Pop signals after freeing a node, use TryPush or PushErr to
+// drop the element instead.
+func (stack *BoundedStack[T]) Push(val T) {
+	if stack.TryPush(val) {
+		return
+	}
+	stack.space.L.Lock()
+	defer stack.space.L.Unlock()
+
+	// A Pop that frees a node after the TryPush below fails sees the
+	// counter, and it cannot signal before Wait releases the lock.
+	stack.waiters.Add(1)
+	defer stack.waiters.Add(-1)
+	for !stack.TryPush(val) {
+		stack.space.Wait()
+	}
+}
+
+func (stack *BoundedStack[T]) Pop() (nilVar T, Err error) {
+	ref := stack.unlink(&stack.head)
+	if ref == 0 {
+		return nilVar, stacks.ErrEmpty
+	}
+	stack.size.Add(-1)
+	n := stack.node(ref)
+	value := n.value
+	// The array must not keep the element from the garbage collector.
+	n.exclusive()
+	n.value = nilVar
+	stack.link(&stack.free, ref)
+	if stack.waiters.Load() != 0 {
+		stack.space.L.Lock()
+		stack.space.Signal()
+		stack.space.L.Unlock()
+	}
+	return value, nil
+}
+
+func (stack *BoundedStack[T]) TryPop() (T, bool) {
+	val, err := stack.Pop()
+	return val, err == nil
+}
+
+// Peek reads the top node only if it is still the top after the read is
+// announced, so the owner of a popped node cannot be writing it.
+func (stack *BoundedStack[T]) Peek() (nilVar T, ok bool) {
+	for {
+		word := stack.head.Load()
+		ref, _ := unpack(word)
+		if ref == 0 {
+			return
+		}
+		n := stack.node(ref)
+		n.readers.Add(1)
+		if stack.head.Load() == word {
+			value := n.value
+			n.readers.Add(-1)
+			return value, true
+		}
+		n.readers.Add(-1)
+	}
+}
+
+func (stack *BoundedStack[T]) IsEmpty() bool {
+	ref, _ := unpack(stack.head.Load())
+	return ref == 0
+}
+
+// Size reads a striped counter that TryPush and Pop update after linking and
+// unlinking the top node, like the Treiber stacks do. There is no exact walk:
+// a Pop may move the node under the walk to the free list.
+func (stack *BoundedStack[T]) Size() int {
+	return int(max(stack.size.Load(), 0))
+}
+
+// Cap returns the capacity of the stack.
+func (stack *BoundedStack[T]) Cap() int {
+	return len(stack.nodes)
+}
+
+// CreateBoundedStack returns a stack of at most capacity elements. It panics
+// if capacity is less than 1, such a stack could never take an element.
+// Capacity is limited to math.MaxInt32.
+func CreateBoundedStack[T any](capacity int) BoundedStack[T] {
+	if capacity < 1 {
+		panic("bounded stack capacity must be positive")
+	}
+	return BoundedStack[T]{
+		nodes: make([]node[T], min(capacity, math.MaxInt32)),
+		space: sync.NewCond(&sync.Mutex{}),
+	}
+}
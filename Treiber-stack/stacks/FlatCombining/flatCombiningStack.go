@@ -0,0 +1,218 @@
+package FlatCombining
+
+import (
+	"Treiber-stack/stacks"
+	"math/rand"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// combinePasses bounds how many times a combiner scans the publication list,
+// so that one goroutine does not serve the others forever.
+const combinePasses = 4
+
+// agingCombines is how many combines a record may stay unused before the
+// combiner removes it from the publication list.
+const agingCombines = 64
+
+// FlatCombiningStack is the flat-combining stack of Hendler, Incze, Shavit and
+// Tzafrir. An operation publishes a request in a record and tries to take the
+// combiner lock; the goroutine that gets it scans the publication list and
+// applies all pending requests to a sequential stack, the others wait for
+// their records to be served. Pushes and pops of one scan are paired off
+// first, like in the elimination array.
+//
+// There is no goroutine-local storage in Go, so the records are not owned by
+// goroutines: they are a fixed array, and an operation claims a free one for
+// its duration. A record joins the publication list when it is first used and
+// the combiner removes it after agingCombines combines without a request, so
+// the scan only visits the records in use. When all records are claimed, the
+// operation takes the combiner lock and applies itself.
+type FlatCombiningStack[T any] struct {
+	lock        sync.Mutex
+	items       []T
+	records     []FCRecord[T]
+	publication atomic.Pointer[FCRecord[T]]
+	// combines counts the combines, pushes and pops are the scratch lists of
+	// a scan, they are used under the lock.
+	combines     uint64
+	pushes, pops []*FCRecord[T]
+}
+
+type FCRecord[T any] struct {
+	// claimed is set while an operation uses the record, active while it is
+	// in the publication list.
+	claimed atomic.Bool
+	active  atomic.Bool
+	// pending is set by the operation after the request and cleared by the
+	// combiner after the response.
+	pending atomic.Bool
+	isPush  bool
+	value   T
+	err     error
+	// used is the combine that last served the record.
+	used uint64
+	next atomic.Pointer[FCRecord[T]]
+}
+
+func (stack *FlatCombiningStack[T]) Push(val T) {
+	stack.apply(true, val)
+}
+
+func (stack *FlatCombiningStack[T]) Pop() (nilVar T, Err error) {
+	return stack.apply(false, nilVar)
+}
+
+func (stack *FlatCombiningStack[T]) TryPop() (T, bool) {
+	val, err := stack.Pop()
+	return val, err == nil
+}
+
+// claim returns a free record, or nil if all are claimed.
+func (stack *FlatCombiningStack[T]) claim() *FCRecord[T] {
+	start := rand.Intn(len(stack.records))
+	for i := range stack.records {
+		record := &stack.records[(start+i)%len(stack.records)]
+		if !record.claimed.Load() && record.claimed.CompareAndSwap(false, true) {
+			return record
+		}
+	}
+	return nil
+}
+
+// publish puts an inactive record at the head of the publication list.
+func (stack *FlatCombiningStack[T]) publish(record *FCRecord[T]) {
+	if !record.active.CompareAndSwap(false, true) {
+		return
+	}
+	for {
+		head := stack.publication.Load()
+		record.next.Store(head)
+		if stack.publication.CompareAndSwap(head, record) {
+			return
+		}
+	}
+}
+
+func (stack *FlatCombiningStack[T]) apply(isPush bool, val T) (nilVar T, Err error) {
+	record := stack.claim()
+	if record == nil {
+		stack.lock.Lock()
+		defer stack.lock.Unlock()
+		if isPush {
+			stack.items = append(stack.items, val)
+			return nilVar, nil
+		}
+		return stack.pop()
+	}
+
+	record.isPush, record.value, record.err = isPush, val, nil
+	record.pending.Store(true)
+	for record.pending.Load() {
+		// The combiner may have just removed the record from the list.
+		stack.publish(record)
+		if stack.lock.TryLock() {
+			stack.combine()
+			stack.lock.Unlock()
+		} else {
+			runtime.Gosched()
+		}
+	}
+	val, err := record.value, record.err
+	record.value = nilVar
+	record.claimed.Store(false)
+	return val, err
+}
+
+// pop pops the top of the sequential stack, the lock must be held.
+func (stack *FlatCombiningStack[T]) pop() (nilVar T, Err error) {
+	if len(stack.items) == 0 {
+		return nilVar, stacks.ErrEmpty
+	}
+	val := stack.items[len(stack.items)-1]
+	stack.items[len(stack.items)-1] = nilVar
+	stack.items = stack.items[:len(stack.items)-1]
+	return val, nil
+}
+
+// combine serves the pending records, the lock must be held.
+func (stack *FlatCombiningStack[T]) combine() {
+	stack.combines++
+	for pass := 0; pass < combinePasses; pass++ {
+		if !stack.scan() {
+			return
+		}
+		// All operations of the scan are pending, so a push and a pop may
+		// cancel out without touching the stack.
+		matched := min(len(stack.pushes), len(stack.pops))
+		for i := 0; i < matched; i++ {
+			stack.pops[i].value, stack.pops[i].err = stack.pushes[i].value, nil
+		}
+		for _, push := range stack.pushes[matched:] {
+			stack.items = append(stack.items, push.value)
+		}
+		for _, pop := range stack.pops[matched:] {
+			pop.value, pop.err = stack.pop()
+		}
+		for _, record := range stack.pushes {
+			record.pending.Store(false)
+		}
+		for _, record := range stack.pops {
+			record.pending.Store(false)
+		}
+		clear(stack.pushes)
+		clear(stack.pops)
+	}
+}
+
+// scan collects the pending records into pushes and pops and removes the
+// aged ones from the list. The head is never removed, the publishers CAS it.
+// It reports whether there was a pending record.
+func (stack *FlatCombiningStack[T]) scan() bool {
+	stack.pushes, stack.pops = stack.pushes[:0], stack.pops[:0]
+	var pred *FCRecord[T]
+	for record := stack.publication.Load(); record != nil; {
+		next := record.next.Load()
+		switch {
+		case record.pending.Load():
+			record.used = stack.combines
+			if record.isPush {
+				stack.pushes = append(stack.pushes, record)
+			} else {
+				stack.pops = append(stack.pops, record)
+			}
+		case pred != nil && stack.combines-record.used > agingCombines:
+			pred.next.Store(next)
+			record.active.Store(false)
+			record = next
+			continue
+		}
+		pred, record = record, next
+	}
+	return len(stack.pushes)+len(stack.pops) > 0
+}
+
+func (stack *FlatCombiningStack[T]) Peek() (nilVar T, ok bool) {
+	stack.lock.Lock()
+	defer stack.lock.Unlock()
+	if len(stack.items) == 0 {
+		return
+	}
+	return stack.items[len(stack.items)-1], true
+}
+
+func (stack *FlatCombiningStack[T]) IsEmpty() bool {
+	return stack.Size() == 0
+}
+
+func (stack *FlatCombiningStack[T]) Size() int {
+	stack.lock.Lock()
+	defer stack.lock.Unlock()
+	return len(stack.items)
+}
+
+// CreateFlatCombiningStack returns a stack with four records per GOMAXPROCS.
+func CreateFlatCombiningStack[T any]() FlatCombiningStack[T] {
+	return FlatCombiningStack[T]{records: make([]FCRecord[T], 4*runtime.GOMAXPROCS(0))}
+}
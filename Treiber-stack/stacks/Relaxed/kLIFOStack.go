@@ -0,0 +1,158 @@
+package Relaxed
+
+import (
+	"Treiber-stack/stacks"
+	"Treiber-stack/stacks/Treiber"
+	"math/rand"
+	"sync/atomic"
+)
+
+// KLIFOStack is a relaxed stack spread over k+1 TreiberStack shards: Pop
+// returns one of the k+1 most recently pushed elements instead of the last
+// one, and operations on different shards do not contend.
+//
+// The shards are filled in layers like in the k-stack of Henzinger et al. The
+// sizes of all shards stay within level and level+1: Push goes to a shard of
+// size level and raises the level when there is none, Pop takes from a shard
+// of size level+1 and lowers the level when there is none. Every element
+// pushed after the popped one is then on top of another shard, so at most k
+// of them are skipped. The bound is exact for sequential use, concurrent
+// operations may break it by the number of operations in flight.
+//
+// Push counts its element in the size of the shard after pushing it and Pop
+// uncounts one before popping, so a shard holds at least size elements and a
+// Pop that took a place from size always finds an element. Pop retries the
+// CAS on a shard until it wins or sees the shard at the level. At level 0 a
+// pass that found nothing is confirmed by reading the shards twice, the size
+// word of a shard carries the number of pushes, so equal reads mean that all
+// shards were empty at once.
+type KLIFOStack[T any] struct {
+	shards []shard[T]
+	level  atomic.Int64
+}
+
+type shard[T any] struct {
+	stack Treiber.TreiberStack[T]
+	// state packs the number of pushes above the size, the number of
+	// elements of stack that Pop may take, see size. Push raises both after
+	// the stack and Pop lowers the size before.
+	state atomic.Uint64
+}
+
+// pushed is the state increment of a Push.
+const pushed = 1<<32 | 1
+
+func size(state uint64) int64 {
+	return int64(uint32(state))
+}
+
+func (stack *KLIFOStack[T]) Push(val T) {
+	for {
+		level := stack.level.Load()
+		start := rand.Intn(len(stack.shards))
+		for i := range stack.shards {
+			sh := &stack.shards[(start+i)%len(stack.shards)]
+			if size(sh.state.Load()) <= level {
+				sh.stack.Push(val)
+				sh.state.Add(pushed)
+				return
+			}
+		}
+		// The layer is full.
+		stack.level.CompareAndSwap(level, level+1)
+	}
+}
+
+func (stack *KLIFOStack[T]) Pop() (nilVar T, Err error) {
+	for {
+		level := stack.level.Load()
+		start := rand.Intn(len(stack.shards))
+		for i := range stack.shards {
+			sh := &stack.shards[(start+i)%len(stack.shards)]
+			for state := sh.state.Load(); size(state) > level; state = sh.state.Load() {
+				if sh.state.CompareAndSwap(state, state-1) {
+					val, _ := sh.stack.Pop()
+					return val, nil
+				}
+			}
+		}
+		if level == 0 {
+			if stack.empty() {
+				return nilVar, stacks.ErrEmpty
+			}
+			continue
+		}
+		// The layer is empty.
+		stack.level.CompareAndSwap(level, level-1)
+	}
+}
+
+// empty reports whether all shards were empty at some moment of the call.
+// A shard read empty changes only after a push, which raises its state by more
+// than the pops that follow can lower it, so the sums of both reads are equal
+// only if no shard was pushed to after it was read empty.
+func (stack *KLIFOStack[T]) empty() bool {
+	var sum uint64
+	for i := range stack.shards {
+		state := stack.shards[i].state.Load()
+		if size(state) != 0 {
+			return false
+		}
+		sum += state
+	}
+	for i := range stack.shards {
+		sum -= stack.shards[i].state.Load()
+	}
+	return sum == 0
+}
+
+func (stack *KLIFOStack[T]) TryPop() (T, bool) {
+	val, err := stack.Pop()
+	return val, err == nil
+}
+
+// Peek returns the top of a shard in the top layer.
+func (stack *KLIFOStack[T]) Peek() (nilVar T, ok bool) {
+	level := stack.level.Load()
+	for i := range stack.shards {
+		if sh := &stack.shards[i]; size(sh.state.Load()) > level {
+			if val, ok := sh.stack.Peek(); ok {
+				return val, true
+			}
+		}
+	}
+	for i := range stack.shards {
+		if val, ok := stack.shards[i].stack.Peek(); ok {
+			return val, true
+		}
+	}
+	return
+}
+
+func (stack *KLIFOStack[T]) IsEmpty() bool {
+	for i := range stack.shards {
+		if !stack.shards[i].stack.IsEmpty() {
+			return false
+		}
+	}
+	return true
+}
+
+func (stack *KLIFOStack[T]) Size() int {
+	elemCounter := 0
+	for i := range stack.shards {
+		elemCounter += stack.shards[i].stack.Size()
+	}
+	return elemCounter
+}
+
+// K returns the relaxation bound.
+func (stack *KLIFOStack[T]) K() int {
+	return len(stack.shards) - 1
+}
+
+// CreateKLIFOStack returns a stack relaxed by k, a non-positive k gives a
+// strict stack on one shard.
+func CreateKLIFOStack[T any](k int) KLIFOStack[T] {
+	return KLIFOStack[T]{shards: make([]shard[T], max(k, 0)+1)}
+}
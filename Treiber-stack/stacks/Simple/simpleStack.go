@@ -1,7 +1,7 @@
 package Simple
 
 import (
-	"errors"
+	"Treiber-stack/stacks"
 )
 
 type SimpleStack[T any] struct {
@@ -13,25 +13,63 @@ type Node[T any] struct {
 	next  *Node[T]
 }
 
-func (stack *SimpleStack[T]) Peek() T {
-	return stack.head.value
+func (stack *SimpleStack[T]) Peek() (nilVar T, ok bool) {
+	if stack.head == nil {
+		return
+	}
+	return stack.head.value, true
 }
 
 func (stack *SimpleStack[T]) Pop() (T, error) {
 	if stack.head == nil {
 		var nilVal T
-		return nilVal, errors.New("stack is already empty")
+		return nilVal, stacks.ErrEmpty
 	}
 	lastValue := stack.head.value
 	stack.head = stack.head.next
 	return lastValue, nil
 }
 
+func (stack *SimpleStack[T]) TryPop() (T, bool) {
+	val, err := stack.Pop()
+	return val, err == nil
+}
+
 func (stack *SimpleStack[T]) Push(val T) {
 	newNode := Node[T]{value: val}
 	stack.head, newNode.next = &newNode, stack.head
 }
 
+// PushAll pushes the items in order, the last one ends up on top.
+func (stack *SimpleStack[T]) PushAll(items ...T) {
+	for _, val := range items {
+		stack.Push(val)
+	}
+}
+
+// PopN pops up to n elements, the top one first.
+func (stack *SimpleStack[T]) PopN(n int) []T {
+	var values []T
+	for ; n > 0 && stack.head != nil; n-- {
+		values = append(values, stack.head.value)
+		stack.head = stack.head.next
+	}
+	return values
+}
+
+// Drain pops all elements, the top one first.
+func (stack *SimpleStack[T]) Drain() []T {
+	var values []T
+	for ; stack.head != nil; stack.head = stack.head.next {
+		values = append(values, stack.head.value)
+	}
+	return values
+}
+
+func (stack *SimpleStack[T]) IsEmpty() bool {
+	return stack.head == nil
+}
+
 func (stack *SimpleStack[T]) Size() int {
 	elemCounter := 0
 	if stack == nil || stack.head == nil {
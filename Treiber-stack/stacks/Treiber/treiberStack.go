@@ -1,12 +1,18 @@
 package Treiber
 
 import (
-	"errors"
+	"Treiber-stack/counter"
+	"Treiber-stack/reclamation"
+	"Treiber-stack/stacks"
 	"sync/atomic"
 )
 
 type TreiberStack[T any] struct {
 	head atomic.Pointer[TNode[T]]
+	size counter.Striped
+	// reclaim recycles popped nodes, it is nil unless the stack is created by
+	// CreateRecyclingTreiberStack.
+	reclaim *reclamation.EBR[TNode[T]]
 }
 
 type TNode[T any] struct {
@@ -15,44 +21,188 @@ type TNode[T any] struct {
 }
 
 func (stack *TreiberStack[T]) Pop() (nilVar T, Err error) {
+	if stack.reclaim != nil {
+		// A popped node may be pushed again, so the head must not be reused
+		// while another Pop holds it between Load and CompareAndSwap.
+		guard := stack.reclaim.Enter()
+		defer stack.reclaim.Exit(guard)
+		for {
+			head := stack.head.Load()
+			if head == nil {
+				return nilVar, stacks.ErrEmpty
+			}
+			if stack.head.CompareAndSwap(head, head.next.Load()) {
+				stack.size.Add(-1)
+				value := head.value
+				stack.reclaim.Retire(guard, head)
+				return value, nil
+			}
+		}
+	}
+
 	for {
 		head := stack.head.Load()
 		if head == nil {
-			return nilVar, errors.New("nil pointer to stack")
+			return nilVar, stacks.ErrEmpty
 		}
 		if stack.head.CompareAndSwap(head, head.next.Load()) {
+			stack.size.Add(-1)
 			return head.value, nil
 		}
 	}
 }
 
+func (stack *TreiberStack[T]) TryPop() (T, bool) {
+	val, err := stack.Pop()
+	return val, err == nil
+}
+
+func (stack *TreiberStack[T]) newNode(val T) *TNode[T] {
+	if stack.reclaim != nil {
+		n := stack.reclaim.Alloc()
+		n.value = val
+		return n
+	}
+	return &TNode[T]{value: val}
+}
+
 func (stack *TreiberStack[T]) Push(val T) {
-	newHead := TNode[T]{value: val}
+	newHead := stack.newNode(val)
 	for {
 		head := stack.head.Load()
 		newHead.next.Store(head)
-		if stack.head.CompareAndSwap(head, &newHead) {
+		if stack.head.CompareAndSwap(head, newHead) {
+			stack.size.Add(1)
+			return
+		}
+	}
+}
+
+// PushAll pushes the items with one CAS, so no other element gets between
+// them. The last item ends up on top, as if they were pushed one by one.
+func (stack *TreiberStack[T]) PushAll(items ...T) {
+	if len(items) == 0 {
+		return
+	}
+	bottom := stack.newNode(items[0])
+	top := bottom
+	for _, val := range items[1:] {
+		n := stack.newNode(val)
+		n.next.Store(top)
+		top = n
+	}
+	for {
+		head := stack.head.Load()
+		bottom.next.Store(head)
+		if stack.head.CompareAndSwap(head, top) {
+			stack.size.Add(int64(len(items)))
 			return
 		}
 	}
 }
 
-func (stack *TreiberStack[T]) Peek() (nilVar T) {
+// PopN pops up to n elements with one CAS, the top one first. It returns
+// fewer elements only if the stack holds fewer.
+func (stack *TreiberStack[T]) PopN(n int) []T {
+	if n <= 0 {
+		return nil
+	}
+	var guard reclamation.Guard
+	if stack.reclaim != nil {
+		guard = stack.reclaim.Enter()
+		defer stack.reclaim.Exit(guard)
+	}
+	for {
+		head := stack.head.Load()
+		if head == nil {
+			return nil
+		}
+		// Nodes below an unchanged head are unchanged too: a node is
+		// pushed again only after it is reclaimed, not while it is held.
+		last, count := head, 1
+		for next := last.next.Load(); count < n && next != nil; next = last.next.Load() {
+			last, count = next, count+1
+		}
+		if stack.head.CompareAndSwap(head, last.next.Load()) {
+			return stack.collect(guard, head, count)
+		}
+	}
+}
+
+// Drain atomically takes all elements, the top one first.
+func (stack *TreiberStack[T]) Drain() []T {
+	var guard reclamation.Guard
+	if stack.reclaim != nil {
+		guard = stack.reclaim.Enter()
+		defer stack.reclaim.Exit(guard)
+	}
+	head := stack.head.Swap(nil)
+	if head == nil {
+		return nil
+	}
+	count := 0
+	for n := head; n != nil; n = n.next.Load() {
+		count++
+	}
+	return stack.collect(guard, head, count)
+}
+
+// collect returns the values of count nodes from head, which are no longer in
+// the stack, and retires the nodes.
+func (stack *TreiberStack[T]) collect(guard reclamation.Guard, head *TNode[T], count int) []T {
+	stack.size.Add(-int64(count))
+	values := make([]T, 0, count)
+	for n := head; len(values) < count; {
+		next := n.next.Load()
+		values = append(values, n.value)
+		if stack.reclaim != nil {
+			stack.reclaim.Retire(guard, n)
+		}
+		n = next
+	}
+	return values
+}
+
+func (stack *TreiberStack[T]) Peek() (nilVar T, ok bool) {
 	if stack == nil {
 		return
 	}
+	if stack.reclaim != nil {
+		defer stack.reclaim.Exit(stack.reclaim.Enter())
+	}
 	head := stack.head.Load()
 	if head == nil {
 		return
 	}
-	return head.value
+	return head.value, true
+}
+
+func (stack *TreiberStack[T]) IsEmpty() bool {
+	return stack == nil || stack.head.Load() == nil
 }
 
+// Size reads the striped counter that Push, Pop and the batch operations
+// update after their CAS on the head, so it is O(1) but only approximate while
+// they run: it lags behind the head and sums the stripes at different moments.
+// It is never negative and is exact once the operations finish.
 func (stack *TreiberStack[T]) Size() int {
+	if stack == nil {
+		return 0
+	}
+	return int(max(stack.size.Load(), 0))
+}
+
+// ExactSize walks the list from one head in O(n). The nodes below a head never
+// change, so it is linearizable at the read of the head, and a recycling stack
+// holds an epoch guard so that the popped nodes are not reused during the walk.
+func (stack *TreiberStack[T]) ExactSize() int {
 	elemCounter := 0
 	if stack == nil || stack.head.Load() == nil {
 		return 0
 	}
+	if stack.reclaim != nil {
+		defer stack.reclaim.Exit(stack.reclaim.Enter())
+	}
 	currHead := stack.head.Load()
 	for currHead != nil {
 		elemCounter++
@@ -61,6 +211,18 @@ func (stack *TreiberStack[T]) Size() int {
 	return elemCounter
 }
 
+// Recycles reports whether popped nodes are reused, see CreateRecyclingTreiberStack.
+func (stack *TreiberStack[T]) Recycles() bool {
+	return stack != nil && stack.reclaim != nil
+}
+
 func CreateTreiberStack[T any]() TreiberStack[T] {
 	return TreiberStack[T]{}
 }
+
+// CreateRecyclingTreiberStack returns a stack that reuses popped nodes for
+// later pushes. Epoch-based reclamation makes sure that a node is reused only
+// when no goroutine can still compare the head with it, which prevents ABA.
+func CreateRecyclingTreiberStack[T any]() TreiberStack[T] {
+	return TreiberStack[T]{reclaim: reclamation.NewEBR[TNode[T]](0)}
+}
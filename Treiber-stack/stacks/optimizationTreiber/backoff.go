@@ -0,0 +1,83 @@
+package optimizationTreiber
+
+import (
+	"math/rand"
+	"time"
+)
+
+const (
+	DefaultMinSteps = 16
+	DefaultMaxSteps = 16 * 1024
+	DefaultMinDelay = time.Microsecond
+	DefaultMaxDelay = 100 * time.Microsecond
+)
+
+// Backoff is the policy of waiting between attempts of an operation. The
+// implementations keep no state, so one value is shared by all goroutines.
+type Backoff interface {
+	// Backoff waits after the attempt-th failure in a row, counting from 1.
+	Backoff(attempt int)
+}
+
+type noBackoff struct{}
+
+func (noBackoff) Backoff(int) {}
+
+// NoBackoff retries at once.
+func NoBackoff() Backoff {
+	return noBackoff{}
+}
+
+type exponentialBackoff struct {
+	minSteps, maxSteps int
+}
+
+// NewExponentialBackoff spins for a random number of steps below a limit,
+// which starts at minSteps and doubles on every attempt up to maxSteps.
+// Non-positive values are replaced by DefaultMinSteps and DefaultMaxSteps.
+func NewExponentialBackoff(minSteps, maxSteps int) Backoff {
+	if minSteps <= 0 {
+		minSteps = DefaultMinSteps
+	}
+	if maxSteps <= 0 {
+		maxSteps = DefaultMaxSteps
+	}
+	return exponentialBackoff{minSteps: minSteps, maxSteps: max(minSteps, maxSteps)}
+}
+
+func (b exponentialBackoff) Backoff(attempt int) {
+	steps := rand.Intn(int(limit(int64(b.minSteps), int64(b.maxSteps), attempt))) + 1
+	for i := 0; i < steps; i++ {
+		// Spin.
+	}
+}
+
+type timeBackoff struct {
+	minDelay, maxDelay time.Duration
+}
+
+// NewTimeBackoff sleeps for a random time below a limit, which starts at
+// minDelay and doubles on every attempt up to maxDelay. Non-positive values
+// are replaced by DefaultMinDelay and DefaultMaxDelay.
+func NewTimeBackoff(minDelay, maxDelay time.Duration) Backoff {
+	if minDelay <= 0 {
+		minDelay = DefaultMinDelay
+	}
+	if maxDelay <= 0 {
+		maxDelay = DefaultMaxDelay
+	}
+	return timeBackoff{minDelay: minDelay, maxDelay: max(minDelay, maxDelay)}
+}
+
+func (b timeBackoff) Backoff(attempt int) {
+	time.Sleep(time.Duration(rand.Int63n(limit(int64(b.minDelay), int64(b.maxDelay), attempt)) + 1))
+}
+
+// limit returns minimum * 2^(attempt-1) capped by maximum.
+func limit(minimum, maximum int64, attempt int) int64 {
+	res := minimum
+	for i := 1; i < attempt && res < maximum; i++ {
+		res *= 2
+	}
+	return min(res, maximum)
+}
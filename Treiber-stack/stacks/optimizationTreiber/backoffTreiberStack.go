@@ -1,13 +1,19 @@
 package optimizationTreiber
 
 import (
-	"errors"
+	"Treiber-stack/counter"
+	"Treiber-stack/reclamation"
+	"Treiber-stack/stacks"
 	"sync/atomic"
 )
 
 type OptimizedTreiberStack[T any] struct {
 	head             atomic.Pointer[OTNode[T]]
-	eliminationArray eliminationArray[T]
+	size             counter.Striped
+	eliminationArray *eliminationArray[T]
+	backoff          Backoff
+	// reclaim recycles popped nodes, it is nil without WithNodeRecycling.
+	reclaim *reclamation.EBR[OTNode[T]]
 }
 
 type OTNode[T any] struct {
@@ -15,31 +21,57 @@ type OTNode[T any] struct {
 	next  atomic.Pointer[OTNode[T]]
 }
 
-func (stack *OptimizedTreiberStack[T]) TryPop() (nilVar *T, Err error) {
+func (stack *OptimizedTreiberStack[T]) TryPop() (T, bool) {
+	val, err := stack.Pop()
+	return val, err == nil
+}
+
+// tryPop makes one attempt to pop, ok is false if the CAS failed.
+func (stack *OptimizedTreiberStack[T]) tryPop() (value T, ok bool, err error) {
+	if stack.reclaim != nil {
+		guard := stack.reclaim.Enter()
+		defer stack.reclaim.Exit(guard)
+		head := stack.head.Load()
+		if head == nil {
+			return value, false, stacks.ErrEmpty
+		}
+		if stack.head.CompareAndSwap(head, head.next.Load()) {
+			stack.size.Add(-1)
+			value = head.value
+			stack.reclaim.Retire(guard, head)
+			return value, true, nil
+		}
+		return
+	}
+
 	head := stack.head.Load()
 	if head == nil {
-		return nilVar, errors.New("nil pointer to stack")
+		return value, false, stacks.ErrEmpty
 	}
 	if stack.head.CompareAndSwap(head, head.next.Load()) {
-		return &head.value, nil
+		stack.size.Add(-1)
+		return head.value, true, nil
 	}
 	return
 }
 
 func (stack *OptimizedTreiberStack[T]) Pop() (nilVar T, Err error) {
-	for {
-		val, err := stack.TryPop()
+	for attempt := 1; ; attempt++ {
+		val, ok, err := stack.tryPop()
 		if err != nil {
 			return nilVar, err
 		}
-		if val != nil {
-			return *val, nil
+		if ok {
+			return val, nil
 		}
-		valVisit, err := stack.eliminationArray.visit(nil)
-		if val != nil && valVisit == nil {
-			return *valVisit, nil
+		if stack.eliminationArray != nil {
+			valVisit, err := stack.eliminationArray.visit(nil)
+			if err == nil && valVisit != nil {
+				// Eliminated by a concurrent Push.
+				return *valVisit, nil
+			}
 		}
-
+		stack.backoff.Backoff(attempt)
 	}
 }
 
@@ -49,35 +81,167 @@ func (stack *OptimizedTreiberStack[T]) tryPush(n *OTNode[T]) bool {
 	return stack.head.CompareAndSwap(head, n)
 }
 
+func (stack *OptimizedTreiberStack[T]) newNode(val T) *OTNode[T] {
+	if stack.reclaim != nil {
+		n := stack.reclaim.Alloc()
+		n.value = val
+		return n
+	}
+	return &OTNode[T]{value: val}
+}
+
 func (stack *OptimizedTreiberStack[T]) Push(val T) {
-	newHead := OTNode[T]{value: val}
-	for {
-		if stack.tryPush(&newHead) {
+	newHead := stack.newNode(val)
+	for attempt := 1; ; attempt++ {
+		if stack.tryPush(newHead) {
+			stack.size.Add(1)
 			return
 		}
-		valVisit, err := stack.eliminationArray.visit(&val)
-		if valVisit == nil && err == nil {
+		if stack.eliminationArray != nil {
+			// A copy, so that val does not escape on the fast path.
+			value := val
+			valVisit, err := stack.eliminationArray.visit(&value)
+			if valVisit == nil && err == nil {
+				if stack.reclaim != nil {
+					// The node was never published.
+					stack.reclaim.Release(newHead)
+				}
+				return
+			}
+		}
+		stack.backoff.Backoff(attempt)
+	}
+}
+
+// PushAll pushes the items with one CAS, so no other element gets between
+// them. The last item ends up on top, as if they were pushed one by one. A
+// batch is not eliminated, a failed CAS only backs off.
+func (stack *OptimizedTreiberStack[T]) PushAll(items ...T) {
+	if len(items) == 0 {
+		return
+	}
+	bottom := stack.newNode(items[0])
+	top := bottom
+	for _, val := range items[1:] {
+		n := stack.newNode(val)
+		n.next.Store(top)
+		top = n
+	}
+	for attempt := 1; ; attempt++ {
+		head := stack.head.Load()
+		bottom.next.Store(head)
+		if stack.head.CompareAndSwap(head, top) {
+			stack.size.Add(int64(len(items)))
 			return
 		}
+		stack.backoff.Backoff(attempt)
 	}
 }
 
-func (stack *OptimizedTreiberStack[T]) Peek() (nilVar T) {
+// PopN pops up to n elements with one CAS, the top one first. It returns
+// fewer elements only if the stack holds fewer.
+func (stack *OptimizedTreiberStack[T]) PopN(n int) []T {
+	if n <= 0 {
+		return nil
+	}
+	var guard reclamation.Guard
+	if stack.reclaim != nil {
+		guard = stack.reclaim.Enter()
+		defer stack.reclaim.Exit(guard)
+	}
+	for attempt := 1; ; attempt++ {
+		head := stack.head.Load()
+		if head == nil {
+			return nil
+		}
+		// Nodes below an unchanged head are unchanged too: a node is
+		// pushed again only after it is reclaimed, not while it is held.
+		last, count := head, 1
+		for next := last.next.Load(); count < n && next != nil; next = last.next.Load() {
+			last, count = next, count+1
+		}
+		if stack.head.CompareAndSwap(head, last.next.Load()) {
+			return stack.collect(guard, head, count)
+		}
+		stack.backoff.Backoff(attempt)
+	}
+}
+
+// Drain atomically takes all elements, the top one first.
+func (stack *OptimizedTreiberStack[T]) Drain() []T {
+	var guard reclamation.Guard
+	if stack.reclaim != nil {
+		guard = stack.reclaim.Enter()
+		defer stack.reclaim.Exit(guard)
+	}
+	head := stack.head.Swap(nil)
+	if head == nil {
+		return nil
+	}
+	count := 0
+	for n := head; n != nil; n = n.next.Load() {
+		count++
+	}
+	return stack.collect(guard, head, count)
+}
+
+// collect returns the values of count nodes from head, which are no longer in
+// the stack, and retires the nodes.
+func (stack *OptimizedTreiberStack[T]) collect(guard reclamation.Guard, head *OTNode[T], count int) []T {
+	stack.size.Add(-int64(count))
+	values := make([]T, 0, count)
+	for n := head; len(values) < count; {
+		next := n.next.Load()
+		values = append(values, n.value)
+		if stack.reclaim != nil {
+			stack.reclaim.Retire(guard, n)
+		}
+		n = next
+	}
+	return values
+}
+
+func (stack *OptimizedTreiberStack[T]) Peek() (nilVar T, ok bool) {
 	if stack == nil {
 		return
 	}
+	if stack.reclaim != nil {
+		defer stack.reclaim.Exit(stack.reclaim.Enter())
+	}
 	head := stack.head.Load()
 	if head == nil {
 		return
 	}
-	return head.value
+	return head.value, true
 }
 
+func (stack *OptimizedTreiberStack[T]) IsEmpty() bool {
+	return stack == nil || stack.head.Load() == nil
+}
+
+// Size reads the striped counter, approximate under concurrent operations
+// like TreiberStack.Size. A push and a pop that meet in the elimination array
+// never reach the list and do not touch the counter either, so only the
+// operations that won the CAS on the head update it.
 func (stack *OptimizedTreiberStack[T]) Size() int {
+	if stack == nil {
+		return 0
+	}
+	return int(max(stack.size.Load(), 0))
+}
+
+// ExactSize walks the list like TreiberStack.ExactSize, under an epoch guard
+// with WithNodeRecycling. A Push waiting in the elimination array is not
+// counted: it takes effect only when a Pop takes its value or it falls back
+// to the list.
+func (stack *OptimizedTreiberStack[T]) ExactSize() int {
 	elemCounter := 0
 	if stack == nil || stack.head.Load() == nil {
 		return 0
 	}
+	if stack.reclaim != nil {
+		defer stack.reclaim.Exit(stack.reclaim.Enter())
+	}
 	currHead := stack.head.Load()
 	for currHead != nil {
 		elemCounter++
@@ -86,6 +250,23 @@ func (stack *OptimizedTreiberStack[T]) Size() int {
 	return elemCounter
 }
 
-func CreateBackoffTreiberStack[T any]() OptimizedTreiberStack[T] {
-	return OptimizedTreiberStack[T]{eliminationArray: newEliminationArray[T](10, 1000)}
+// Recycles reports whether popped nodes are reused, see WithNodeRecycling.
+func (stack *OptimizedTreiberStack[T]) Recycles() bool {
+	return stack != nil && stack.reclaim != nil
+}
+
+// CreateBackoffTreiberStack returns a stack with an elimination array of
+// DefaultEliminationCapacity exchangers waiting DefaultWaitSteps steps, no
+// backoff and no node recycling, unless opts say otherwise.
+func CreateBackoffTreiberStack[T any](opts ...Option) OptimizedTreiberStack[T] {
+	o := applyOptions(opts)
+	var elArr *eliminationArray[T]
+	if o.elimination {
+		elArr = newEliminationArray[T](o.capacity, o.waitSteps)
+	}
+	var reclaim *reclamation.EBR[OTNode[T]]
+	if o.recycle {
+		reclaim = reclamation.NewEBR[OTNode[T]](0)
+	}
+	return OptimizedTreiberStack[T]{eliminationArray: elArr, backoff: o.backoff, reclaim: reclaim}
 }
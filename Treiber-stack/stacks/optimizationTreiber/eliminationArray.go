@@ -1,22 +1,97 @@
 package optimizationTreiber
 
-import "math/rand"
+import (
+	"errors"
+	"math/rand"
+	"sync/atomic"
+)
 
+// eliminationArray adapts to the load of its stack as in Hendler, Shavit and
+// Yerushalmi: a visitor picks an exchanger among the first rangeSize ones and
+// waits for a partner for spin steps. Timeouts mean there are too few
+// partners, so the range shrinks to make them meet and the wait gets shorter,
+// which stops phases of only pushes or only pops from paying for elimination
+// that cannot succeed. Collisions, meetings of two operations of the same
+// kind, mean there are many visitors, so the range grows, and the wait gets
+// shorter as well, since such phases mostly collide. A successful exchange
+// restores the full wait.
+//
+// Even the shortest wait costs more than a retry of the CAS when no partner
+// comes, so after disableAfter visits in a row without an exchange, timeouts
+// or collisions, the array is bypassed: visit fails at once and the stack
+// retries the CAS directly. Every probeEvery-th visit still tries an
+// exchanger, and a successful exchange turns elimination back on.
+//
+// The state is per stack, there is no goroutine-local storage in Go. It is
+// a heuristic, so concurrent updates may overwrite each other.
 type eliminationArray[T any] struct {
 	cap, waitSteps int
-	exchangers     []exchanger[T]
+	exchangers     []Exchanger[*T]
+	rangeSize      atomic.Int64
+	spin           atomic.Int64
+	// failures counts the visits in a row without an exchange, probes the
+	// visits while the array is bypassed.
+	failures atomic.Int64
+	probes   atomic.Int64
 }
 
+// errCollision means that two operations of the same kind met in an
+// exchanger, two pushes or two pops cannot eliminate each other.
+var errCollision = errors.New("collision")
+
+// minSpinShift bounds the wait from below by waitSteps >> minSpinShift.
+const minSpinShift = 5
+
+const (
+	// disableAfter is how many visits in a row without an exchange make the
+	// array bypassed.
+	disableAfter = 16
+	// probeEvery is how often a visit of a bypassed array tries an exchanger.
+	probeEvery = 64
+)
+
 func (elArr *eliminationArray[T]) visit(value *T) (*T, error) {
-	index := rand.Intn(elArr.cap)
-	return elArr.exchangers[index].exchange(value, elArr.waitSteps)
-}
+	if elArr.failures.Load() >= disableAfter && elArr.probes.Add(1)%probeEvery != 0 {
+		return nil, ErrTimeout
+	}
+	rangeSize, spin := elArr.rangeSize.Load(), elArr.spin.Load()
+	index := rand.Intn(int(rangeSize))
+	res, err := elArr.exchangers[index].exchangeSteps(value, int(spin))
+	if err == nil && (res == nil) == (value == nil) {
+		res, err = nil, errCollision
+	}
 
-func newEliminationArray[T any](cap, waitSteps int) eliminationArray[T] {
-	newArr := eliminationArray[T]{cap: cap, waitSteps: waitSteps}
-	newArr.exchangers = make([]exchanger[T], cap)
-	for i := range newArr.exchangers {
-		newArr.exchangers[i] = newExchanger[T]()
+	switch err {
+	case nil:
+		elArr.spin.Store(int64(elArr.waitSteps))
+		if elArr.failures.Load() != 0 {
+			elArr.failures.Store(0)
+		}
+	case ErrTimeout:
+		if rangeSize > 1 {
+			elArr.rangeSize.CompareAndSwap(rangeSize, rangeSize-1)
+		}
+		elArr.shorten(spin)
+		elArr.failures.Add(1)
+	case errCollision:
+		if rangeSize < int64(elArr.cap) {
+			elArr.rangeSize.CompareAndSwap(rangeSize, rangeSize+1)
+		}
+		elArr.shorten(spin)
+		elArr.failures.Add(1)
 	}
+	return res, err
+}
+
+// shorten halves the wait down to waitSteps >> minSpinShift.
+func (elArr *eliminationArray[T]) shorten(spin int64) {
+	elArr.spin.CompareAndSwap(spin, max(spin/2, int64(elArr.waitSteps>>minSpinShift), 1))
+}
+
+func newEliminationArray[T any](cap, waitSteps int) *eliminationArray[T] {
+	newArr := &eliminationArray[T]{cap: cap, waitSteps: waitSteps}
+	newArr.exchangers = make([]Exchanger[*T], cap)
+	newArr.rangeSize.Store(int64(cap))
+	newArr.spin.Store(int64(waitSteps))
 	return newArr
 }
@@ -1,62 +1,164 @@
 package optimizationTreiber
 
 import (
+	"context"
 	"errors"
+	"runtime"
 	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrTimeout means that no partner came in time.
+	ErrTimeout = errors.New("exchange timed out")
+	// ErrCanceled means that the context of the exchange was canceled.
+	ErrCanceled = errors.New("exchange canceled")
 )
 
 type exchangerState int
 
 const (
-	empty exchangerState = iota
-	wait
+	wait exchangerState = iota
 	busy
 )
 
-type exchanger[T any] struct {
-	item atomic.Value
+// parkSteps is how many steps Exchange and ExchangeTimeout spin on their offer
+// before they park until it is answered.
+const parkSteps = 64
+
+// Exchanger is the lock-free exchanger of Herlihy and Shavit (The Art of
+// Multiprocessor Programming, chapter 11): two goroutines calling Exchange
+// meet in its slot and swap their values. The zero value is ready to use.
+//
+// The slot is empty (nil), holds the offer of a waiting goroutine, or holds the
+// answer of the partner that took the offer. Slots are never reused, so a CAS
+// on the pointer cannot suffer from ABA. An offer of Exchange or
+// ExchangeTimeout carries a channel that the partner closes after answering,
+// so a goroutine that spun for parkSteps steps sleeps on it instead.
+type Exchanger[T any] struct {
+	slot atomic.Pointer[exchangeSlot[T]]
 }
 
-type exchangeItem[T any] struct {
-	value *T
+type exchangeSlot[T any] struct {
+	value T
 	state exchangerState
+	// answered is closed by the partner, it is nil if the offer never parks.
+	answered chan struct{}
 }
 
-func newExchanger[T any]() exchanger[T] {
-	newEx := exchanger[T]{}
-	newEx.item.Store(exchangeItem[T]{state: empty})
-	return newEx
+// Exchange waits for a partner and returns its value, or returns ErrTimeout
+// or ErrCanceled when ctx is done first.
+func (ex *Exchanger[T]) Exchange(ctx context.Context, value T) (T, error) {
+	done := ctx.Done()
+	return ex.exchange(value, func(int) error {
+		select {
+		case <-done:
+			return contextErr(ctx)
+		default:
+			runtime.Gosched()
+			return nil
+		}
+	}, func(answered <-chan struct{}) error {
+		select {
+		case <-answered:
+			return nil
+		case <-done:
+			return contextErr(ctx)
+		}
+	})
 }
 
-func (ex *exchanger[T]) exchange(val *T, waitSteps int) (*T, error) {
-	emptyCase := func(passSteps int) (*T, error) {
-		for j := passSteps; j < waitSteps; j++ {
-			exItem := ex.item.Load().(exchangeItem[T])
-			if exItem.state == busy {
-				newItem := exchangeItem[T]{state: empty}
-				ex.item.Store(newItem)
-				return exItem.value, nil
-			}
-		}
-		return new(T), errors.New("end cycle")
+// contextErr translates the error of a done context.
+func contextErr(ctx context.Context) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return ErrTimeout
 	}
+	return ErrCanceled
+}
+
+// ExchangeTimeout waits for a partner for at most timeout.
+func (ex *Exchanger[T]) ExchangeTimeout(value T, timeout time.Duration) (T, error) {
+	deadline := time.Now().Add(timeout)
+	return ex.exchange(value, func(int) error {
+		if time.Now().After(deadline) {
+			return ErrTimeout
+		}
+		runtime.Gosched()
+		return nil
+	}, func(answered <-chan struct{}) error {
+		timer := time.NewTimer(time.Until(deadline))
+		defer timer.Stop()
+		select {
+		case <-answered:
+			return nil
+		case <-timer.C:
+			return ErrTimeout
+		}
+	})
+}
 
-	for i := 0; i < waitSteps; i++ {
-		exItem := ex.item.Load().(exchangeItem[T])
+// exchangeSteps spins for at most waitSteps steps and never parks, it is what
+// the elimination array uses: there a partner is only worth a short wait.
+func (ex *Exchanger[T]) exchangeSteps(value T, waitSteps int) (T, error) {
+	return ex.exchange(value, func(step int) error {
+		if step >= waitSteps {
+			return ErrTimeout
+		}
+		return nil
+	}, nil)
+}
+
+// exchange runs the exchange protocol, stop is called before every step with
+// its number and ends the wait when it returns an error. If park is not nil,
+// an offer that is not answered in parkSteps steps calls it to sleep until
+// answered is closed, and the wait ends if it returns an error.
+func (ex *Exchanger[T]) exchange(value T, stop func(step int) error, park func(answered <-chan struct{}) error) (nilVar T, Err error) {
+	for step := 0; ; step++ {
+		if err := stop(step); err != nil {
+			return nilVar, err
+		}
+		slot := ex.slot.Load()
 
-		if exItem.state == empty {
-			oldItem := exchangeItem[T]{state: empty}
-			newItem := exchangeItem[T]{value: val, state: wait}
-			if ex.item.CompareAndSwap(oldItem, newItem) {
-				return emptyCase(i)
+		if slot == nil {
+			offer := &exchangeSlot[T]{value: value, state: wait}
+			if park != nil {
+				offer.answered = make(chan struct{})
+			}
+			if ex.slot.CompareAndSwap(nil, offer) {
+				return ex.await(offer, step+1, stop, park)
+			}
+		} else if slot.state == wait {
+			if ex.slot.CompareAndSwap(slot, &exchangeSlot[T]{value: value, state: busy}) {
+				if slot.answered != nil {
+					close(slot.answered)
+				}
+				return slot.value, nil
 			}
-		} else if exItem.state == wait {
-			oldItem := exchangeItem[T]{value: exItem.value, state: wait}
-			newItem := exchangeItem[T]{value: val, state: busy}
-			if ex.item.CompareAndSwap(oldItem, newItem) {
-				return exItem.value, nil
+		}
+		// The slot is busy with another pair, wait until it is released.
+	}
+}
+
+// await waits until a partner answers the offer, spinning first and parking
+// after parkSteps steps. On failure the offer is withdrawn, unless a partner
+// has just answered it.
+func (ex *Exchanger[T]) await(offer *exchangeSlot[T], step int, stop func(step int) error, park func(answered <-chan struct{}) error) (nilVar T, Err error) {
+	for first := step; ; step++ {
+		if answer := ex.slot.Load(); answer != offer {
+			ex.slot.Store(nil)
+			return answer.value, nil
+		}
+		err := stop(step)
+		if err == nil && park != nil && step-first >= parkSteps {
+			err = park(offer.answered)
+		}
+		if err != nil {
+			if ex.slot.CompareAndSwap(offer, nil) {
+				return nilVar, err
 			}
+			answer := ex.slot.Load()
+			ex.slot.Store(nil)
+			return answer.value, nil
 		}
 	}
-	return new(T), errors.New("end cycle")
 }
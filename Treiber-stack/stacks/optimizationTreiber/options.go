@@ -0,0 +1,81 @@
+package optimizationTreiber
+
+const (
+	DefaultEliminationCapacity = 10
+	DefaultWaitSteps           = 1000
+)
+
+// Option configures the stack created by CreateBackoffTreiberStack.
+type Option func(*stackOptions)
+
+type stackOptions struct {
+	capacity    int
+	waitSteps   int
+	elimination bool
+	backoff     Backoff
+	recycle     bool
+}
+
+// WithEliminationCapacity sets the number of exchangers in the elimination
+// array. The adaptive range never grows beyond it.
+func WithEliminationCapacity(capacity int) Option {
+	return func(o *stackOptions) {
+		o.capacity = capacity
+	}
+}
+
+// WithWaitSteps sets how many steps an operation waits for a partner in an
+// exchanger before the range and the wait shrink.
+func WithWaitSteps(waitSteps int) Option {
+	return func(o *stackOptions) {
+		o.waitSteps = waitSteps
+	}
+}
+
+// WithoutElimination turns the elimination array off, so a failed CAS is only
+// followed by the backoff.
+func WithoutElimination() Option {
+	return func(o *stackOptions) {
+		o.elimination = false
+	}
+}
+
+// WithBackoff sets the policy applied after a failed CAS on the head and a
+// failed elimination. The default is NoBackoff.
+func WithBackoff(backoff Backoff) Option {
+	return func(o *stackOptions) {
+		o.backoff = backoff
+	}
+}
+
+// WithNodeRecycling makes the stack reuse popped nodes for later pushes.
+// Epoch-based reclamation makes sure that a node is reused only when no
+// goroutine can still compare the head with it, which prevents ABA.
+func WithNodeRecycling() Option {
+	return func(o *stackOptions) {
+		o.recycle = true
+	}
+}
+
+// applyOptions replaces non-positive capacity and waitSteps by the defaults.
+func applyOptions(opts []Option) stackOptions {
+	o := stackOptions{
+		capacity:    DefaultEliminationCapacity,
+		waitSteps:   DefaultWaitSteps,
+		elimination: true,
+		backoff:     NoBackoff(),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.capacity <= 0 {
+		o.capacity = DefaultEliminationCapacity
+	}
+	if o.waitSteps <= 0 {
+		o.waitSteps = DefaultWaitSteps
+	}
+	if o.backoff == nil {
+		o.backoff = NoBackoff()
+	}
+	return o
+}
@@ -0,0 +1,159 @@
+package optimizationTreiber
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+type syncNodeState int32
+
+const (
+	waiting syncNodeState = iota
+	matched
+	canceled
+)
+
+// SynchronousQueue is the synchronous dual queue of Herlihy and Shavit (The
+// Art of Multiprocessor Programming, chapter 10): Put waits for a Take and
+// Take waits for a Put, the queue has no capacity. Waiting calls are nodes of
+// a lock-free list, either data nodes of Put or reservations of Take, and all
+// of them are of one kind. A call of the other kind fulfills the oldest node,
+// so waiting goroutines are served in FIFO order.
+//
+// The queue is not built on Exchanger: an exchanger pairs any two callers in
+// one slot, so two Puts could swap values, and it keeps no order among the
+// waiting ones.
+//
+// A canceled node is unlinked by its caller at once, unless it is the last
+// one: a node appended after it unlinks it then, and until that a call of the
+// other kind skips it.
+type SynchronousQueue[T any] struct {
+	head    atomic.Pointer[syncQueueNode[T]]
+	tail    atomic.Pointer[syncQueueNode[T]]
+	waiting atomic.Int64
+}
+
+type syncQueueNode[T any] struct {
+	isData bool
+	// value is set at creation for data nodes and by the fulfiller for
+	// reservations, it is read after done is closed.
+	value T
+	state atomic.Int32
+	done  chan struct{}
+	next  atomic.Pointer[syncQueueNode[T]]
+}
+
+func NewSynchronousQueue[T any]() *SynchronousQueue[T] {
+	q := &SynchronousQueue[T]{}
+	sentinel := &syncQueueNode[T]{}
+	q.head.Store(sentinel)
+	q.tail.Store(sentinel)
+	return q
+}
+
+// Put hands value to a Take, waiting for one until ctx is done. It returns
+// ErrTimeout or ErrCanceled if the value was not taken.
+func (q *SynchronousQueue[T]) Put(ctx context.Context, value T) error {
+	_, err := q.transfer(ctx, &syncQueueNode[T]{isData: true, value: value, done: make(chan struct{})})
+	return err
+}
+
+// Take receives the value of a Put, waiting for one until ctx is done. It
+// returns ErrTimeout or ErrCanceled if no value was received.
+func (q *SynchronousQueue[T]) Take(ctx context.Context) (T, error) {
+	return q.transfer(ctx, &syncQueueNode[T]{done: make(chan struct{})})
+}
+
+func (q *SynchronousQueue[T]) transfer(ctx context.Context, node *syncQueueNode[T]) (nilVar T, Err error) {
+	for {
+		head, tail := q.head.Load(), q.tail.Load()
+		if head == tail || tail.isData == node.isData {
+			// Empty or of the same kind: wait at the end.
+			next := tail.next.Load()
+			if tail != q.tail.Load() {
+				continue
+			}
+			if next != nil {
+				q.tail.CompareAndSwap(tail, next)
+				continue
+			}
+			if !tail.next.CompareAndSwap(nil, node) {
+				continue
+			}
+			q.tail.CompareAndSwap(tail, node)
+			if syncNodeState(tail.state.Load()) == canceled {
+				q.unlink(tail)
+			}
+			return q.await(ctx, node)
+		}
+
+		// Of the other kind: fulfill the oldest node.
+		next := head.next.Load()
+		if tail != q.tail.Load() || head != q.head.Load() || next == nil {
+			continue
+		}
+		fulfilled := next.state.CompareAndSwap(int32(waiting), int32(matched))
+		if fulfilled {
+			if next.isData {
+				node.value = next.value
+			} else {
+				next.value = node.value
+			}
+			close(next.done)
+		}
+		q.head.CompareAndSwap(head, next)
+		if fulfilled {
+			return node.value, nil
+		}
+	}
+}
+
+// await waits until the node is fulfilled or ctx is done.
+func (q *SynchronousQueue[T]) await(ctx context.Context, node *syncQueueNode[T]) (nilVar T, Err error) {
+	q.waiting.Add(1)
+	defer q.waiting.Add(-1)
+	select {
+	case <-node.done:
+	case <-ctx.Done():
+		if node.state.CompareAndSwap(int32(waiting), int32(canceled)) {
+			q.unlink(node)
+			return nilVar, contextErr(ctx)
+		}
+		// A fulfiller has already matched the node.
+		<-node.done
+	}
+	q.unlinkHead(node)
+	return node.value, nil
+}
+
+// unlinkHead helps to move the head past the node if it is the first one.
+func (q *SynchronousQueue[T]) unlinkHead(node *syncQueueNode[T]) {
+	if head := q.head.Load(); head.next.Load() == node {
+		q.head.CompareAndSwap(head, node)
+	}
+}
+
+// unlink removes a canceled node from the list, unless it is the last one,
+// whose next is where new nodes are appended. The node keeps its next, so a
+// goroutine standing on it still reaches the rest of the list, and a
+// concurrent unlink of a neighbour may leave it linked, then it is skipped.
+func (q *SynchronousQueue[T]) unlink(node *syncQueueNode[T]) {
+	for pred := q.head.Load(); ; {
+		next := pred.next.Load()
+		if next == nil {
+			return
+		}
+		if next == node {
+			if after := node.next.Load(); after != nil {
+				pred.next.CompareAndSwap(node, after)
+			}
+			return
+		}
+		pred = next
+	}
+}
+
+// Waiting returns the number of Put and Take calls waiting for a partner.
+func (q *SynchronousQueue[T]) Waiting() int {
+	return int(q.waiting.Load())
+}
@@ -1,8 +1,31 @@
 package stacks
 
+import "errors"
+
+// ErrEmpty is returned by Pop of an empty stack.
+var ErrEmpty = errors.New("stack is empty")
+
 type Stack[T any] interface {
 	Push(T)
+	// Pop removes the top element, it returns ErrEmpty if there is none.
 	Pop() (T, error)
-	Peek() T
+	// TryPop is Pop that reports an empty stack with false.
+	TryPop() (T, bool)
+	// Peek returns the top element without removing it, or false if the
+	// stack is empty.
+	Peek() (T, bool)
+	IsEmpty() bool
 	Size() int
 }
+
+// BatchStack moves several elements with one operation, no element of
+// another operation gets between them.
+type BatchStack[T any] interface {
+	Stack[T]
+	// PushAll pushes the items in order, the last one ends up on top.
+	PushAll(items ...T)
+	// PopN pops up to n elements, the top one first.
+	PopN(n int) []T
+	// Drain pops all elements, the top one first.
+	Drain() []T
+}
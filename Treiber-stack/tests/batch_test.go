@@ -0,0 +1,176 @@
+package tests
+
+import (
+	"Treiber-stack/stacks"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func isBatch(myStack stacks.Stack[int]) bool {
+	_, ok := myStack.(stacks.BatchStack[int])
+	return ok
+}
+
+func isConcurrentBatch(myStack stacks.Stack[int]) bool {
+	return isBatch(myStack) && isConcurrent(myStack)
+}
+
+func TestBatchOperations(t *testing.T) {
+	for _, testStruct := range filterStacks(isBatch) {
+		myStack := testStruct.currStack.(stacks.BatchStack[int])
+		myStack.PushAll()
+		checkEmpty(t, myStack, testStruct.typeStack)
+
+		myStack.PushAll(1, 2, 3, 4, 5)
+		myStack.Push(6)
+		if sz := myStack.Size(); sz != 6 {
+			t.Errorf("Expected size 6 of %s stack, but get %d", testStruct.typeStack, sz)
+		}
+		var tests = []struct {
+			n        int
+			expected []int
+		}{
+			{0, nil},
+			{2, []int{6, 5}},
+			{10, []int{4, 3, 2, 1}},
+			{1, nil},
+		}
+		for _, test := range tests {
+			if res := myStack.PopN(test.n); !slices.Equal(res, test.expected) {
+				t.Errorf("PopN(%d) of %s stack: expected %v, but get %v", test.n, testStruct.typeStack, test.expected, res)
+			}
+		}
+
+		if res := myStack.Drain(); len(res) != 0 {
+			t.Errorf("Drain of empty %s stack: expected nothing, but get %v", testStruct.typeStack, res)
+		}
+		myStack.PushAll(1, 2, 3)
+		if res := myStack.Drain(); !slices.Equal(res, []int{3, 2, 1}) {
+			t.Errorf("Drain of %s stack: expected [3 2 1], but get %v", testStruct.typeStack, res)
+		}
+		checkEmpty(t, myStack, testStruct.typeStack)
+	}
+}
+
+// checkBatch reports whether values are one whole batch of batchSize values
+// pushed by PushAll, top first.
+func checkBatch(values []int, batchSize int) bool {
+	if len(values) != batchSize || values[0]%batchSize != batchSize-1 {
+		return false
+	}
+	for i := 1; i < len(values); i++ {
+		if values[i] != values[i-1]-1 {
+			return false
+		}
+	}
+	return true
+}
+
+// TestBatchAtomicity runs PushAll and PopN of whole batches concurrently. The
+// stack then always consists of whole batches, so a PopN that interleaved
+// with another batch operation returns a mixed batch.
+func TestBatchAtomicity(t *testing.T) {
+	const goroutineCount = 8
+	const batches = 500
+	const batchSize = 8
+
+	for _, testStruct := range filterStacks(isConcurrentBatch) {
+		myStack := testStruct.currStack.(stacks.BatchStack[int])
+		seen := make([]bool, goroutineCount*batches*batchSize)
+		mutex := sync.Mutex{}
+		receive := func(values []int) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			for _, val := range values {
+				if seen[val] {
+					t.Errorf("Value %d popped twice from %s stack", val, testStruct.typeStack)
+				}
+				seen[val] = true
+			}
+		}
+
+		wg := sync.WaitGroup{}
+		wg.Add(2 * goroutineCount)
+		for g := 0; g < goroutineCount; g++ {
+			go func(g int) {
+				defer wg.Done()
+				batch := make([]int, batchSize)
+				for i := 0; i < batches; i++ {
+					for j := range batch {
+						batch[j] = (g*batches+i)*batchSize + j
+					}
+					myStack.PushAll(batch...)
+				}
+			}(g)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < batches; i++ {
+					values := myStack.PopN(batchSize)
+					if len(values) == 0 {
+						continue
+					}
+					if !checkBatch(values, batchSize) {
+						t.Errorf("PopN of %s stack returned a mixed batch %v", testStruct.typeStack, values)
+					}
+					receive(values)
+				}
+			}()
+		}
+		wg.Wait()
+
+		rest := myStack.Drain()
+		for i := 0; i < len(rest); i += batchSize {
+			if !checkBatch(rest[i:min(i+batchSize, len(rest))], batchSize) {
+				t.Errorf("Drain of %s stack returned a mixed batch %v", testStruct.typeStack, rest[i:min(i+batchSize, len(rest))])
+			}
+		}
+		receive(rest)
+		for val, ok := range seen {
+			if !ok {
+				t.Errorf("Value %d is lost in %s stack", val, testStruct.typeStack)
+				break
+			}
+		}
+	}
+}
+
+// TestDrainAtomicity drains the stack while goroutines push, every pushed
+// value must be drained exactly once.
+func TestDrainAtomicity(t *testing.T) {
+	const goroutineCount = 8
+	const elements = 5_000
+
+	for _, testStruct := range filterStacks(isConcurrentBatch) {
+		myStack := testStruct.currStack.(stacks.BatchStack[int])
+		var drained []int
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for len(drained) < goroutineCount*elements {
+				drained = append(drained, myStack.Drain()...)
+			}
+		}()
+
+		wg := sync.WaitGroup{}
+		wg.Add(goroutineCount)
+		for g := 0; g < goroutineCount; g++ {
+			go func(g int) {
+				defer wg.Done()
+				for i := 0; i < elements; i++ {
+					myStack.Push(g*elements + i)
+				}
+			}(g)
+		}
+		wg.Wait()
+		<-done
+
+		slices.Sort(drained)
+		for i, val := range drained {
+			if val != i {
+				t.Errorf("Drained values of %s stack are not every pushed value once, %d at %d", testStruct.typeStack, val, i)
+				break
+			}
+		}
+	}
+}
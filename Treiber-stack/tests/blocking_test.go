@@ -0,0 +1,208 @@
+package tests
+
+import (
+	"Treiber-stack/stacks"
+	"Treiber-stack/stacks/Blocking"
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPopWaitCancel(t *testing.T) {
+	for _, testStruct := range filterStacks(isConcurrent) {
+		myStack := Blocking.CreateBlockingStack[int](testStruct.currStack)
+		myStack.Push(1)
+		if res, err := myStack.PopWait(context.Background()); err != nil || res != 1 {
+			t.Errorf("Expected (1, nil) from %s stack, but get (%d, %v)", testStruct.typeStack, res, err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+		if _, err := myStack.PopWait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected DeadlineExceeded from empty %s stack, but get %v", testStruct.typeStack, err)
+		}
+		cancel()
+
+		ctx, cancel = context.WithCancel(context.Background())
+		go func() {
+			time.Sleep(time.Millisecond)
+			cancel()
+		}()
+		if _, err := myStack.PopWait(ctx); !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected Canceled from empty %s stack, but get %v", testStruct.typeStack, err)
+		}
+
+		// The canceled waiters must not swallow later elements.
+		myStack.Push(2)
+		if res, ok := myStack.TryPop(); !ok || res != 2 {
+			t.Errorf("Expected (2, true) from %s stack, but get (%d, %t)", testStruct.typeStack, res, ok)
+		}
+	}
+}
+
+// TestPopWaitWakeUp makes consumers wait while producers push one element at
+// a time, a lost wake-up leaves a consumer waiting until the deadline.
+func TestPopWaitWakeUp(t *testing.T) {
+	const goroutineCount = 8
+	const elements = 2_000
+
+	for _, testStruct := range filterStacks(isConcurrent) {
+		myStack := Blocking.CreateBlockingStack[int](testStruct.currStack)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		var received atomic.Int64
+
+		wg := sync.WaitGroup{}
+		wg.Add(2 * goroutineCount)
+		for g := 0; g < goroutineCount; g++ {
+			go func() {
+				defer wg.Done()
+				for i := 0; i < elements; i++ {
+					if _, err := myStack.PopWait(ctx); err != nil {
+						t.Errorf("Unexpected error in %s stack: %v", testStruct.typeStack, err)
+						return
+					}
+					received.Add(1)
+				}
+			}()
+			go func(g int) {
+				defer wg.Done()
+				for i := 0; i < elements; i++ {
+					myStack.Push(g*elements + i)
+				}
+			}(g)
+		}
+		wg.Wait()
+		cancel()
+
+		if cnt := received.Load(); cnt != goroutineCount*elements {
+			t.Errorf("Expected %d elements from %s stack, but get %d", goroutineCount*elements, testStruct.typeStack, cnt)
+		}
+	}
+}
+
+func TestCloseWakesWaiters(t *testing.T) {
+	const goroutineCount = 8
+
+	for _, testStruct := range filterStacks(isConcurrent) {
+		myStack := Blocking.CreateBlockingStack[int](testStruct.currStack)
+		errs := make(chan error, goroutineCount)
+		for g := 0; g < goroutineCount; g++ {
+			go func() {
+				_, err := myStack.PopWait(context.Background())
+				errs <- err
+			}()
+		}
+		time.Sleep(time.Millisecond)
+		myStack.Close()
+		myStack.Close()
+		for g := 0; g < goroutineCount; g++ {
+			if err := <-errs; !errors.Is(err, Blocking.ErrClosed) {
+				t.Errorf("Expected ErrClosed from %s stack, but get %v", testStruct.typeStack, err)
+			}
+		}
+
+		if err := myStack.PushErr(1); !errors.Is(err, Blocking.ErrClosed) {
+			t.Errorf("Expected ErrClosed from PushErr on closed %s stack, but get %v", testStruct.typeStack, err)
+		}
+		myStack.Push(2)
+		if res, ok := myStack.TryPop(); ok {
+			t.Errorf("Push on closed %s stack was not dropped, popped %d", testStruct.typeStack, res)
+		}
+	}
+}
+
+// TestCloseRace closes the stack while consumers are draining it: every
+// element pushed before Close must be received and then every consumer must
+// get ErrClosed.
+func TestCloseRace(t *testing.T) {
+	const goroutineCount = 8
+	const elements = 5_000
+
+	for _, testStruct := range filterStacks(isConcurrent) {
+		myStack := Blocking.CreateBlockingStack[int](testStruct.currStack)
+		var received atomic.Int64
+
+		consumers := sync.WaitGroup{}
+		consumers.Add(goroutineCount)
+		for g := 0; g < goroutineCount; g++ {
+			go func() {
+				defer consumers.Done()
+				for {
+					_, err := myStack.PopWait(context.Background())
+					if errors.Is(err, Blocking.ErrClosed) {
+						return
+					}
+					if err != nil {
+						t.Errorf("Unexpected error in %s stack: %v", testStruct.typeStack, err)
+						return
+					}
+					received.Add(1)
+				}
+			}()
+		}
+
+		producers := sync.WaitGroup{}
+		producers.Add(goroutineCount)
+		for g := 0; g < goroutineCount; g++ {
+			go func(g int) {
+				defer producers.Done()
+				for i := 0; i < elements; i++ {
+					myStack.Push(g*elements + i)
+				}
+			}(g)
+		}
+		producers.Wait()
+		myStack.Close()
+		consumers.Wait()
+
+		if cnt := received.Load(); cnt != goroutineCount*elements {
+			t.Errorf("Expected %d elements from %s stack, but get %d", goroutineCount*elements, testStruct.typeStack, cnt)
+		}
+		if _, err := myStack.Pop(); !errors.Is(err, stacks.ErrEmpty) {
+			t.Errorf("Expected ErrEmpty from drained %s stack, but get %v", testStruct.typeStack, err)
+		}
+	}
+}
+
+// TestPushCloseRace closes the stack while producers push: every element
+// accepted by PushErr must still be received after Close.
+func TestPushCloseRace(t *testing.T) {
+	const goroutineCount = 8
+	const elements = 2_000
+
+	for _, testStruct := range filterStacks(isConcurrent) {
+		myStack := Blocking.CreateBlockingStack[int](testStruct.currStack)
+		var pushed atomic.Int64
+
+		producers := sync.WaitGroup{}
+		producers.Add(goroutineCount)
+		for g := 0; g < goroutineCount; g++ {
+			go func() {
+				defer producers.Done()
+				for i := 0; i < elements && myStack.PushErr(i) == nil; i++ {
+					pushed.Add(1)
+				}
+			}()
+		}
+		for pushed.Load() < elements {
+			runtime.Gosched()
+		}
+		myStack.Close()
+		producers.Wait()
+
+		received := int64(0)
+		for {
+			_, err := myStack.PopWait(context.Background())
+			if err != nil {
+				break
+			}
+			received++
+		}
+		if cnt := pushed.Load(); received != cnt {
+			t.Errorf("Expected %d elements from %s stack, but get %d", cnt, testStruct.typeStack, received)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package tests
+
+import (
+	"Treiber-stack/stacks/Bounded"
+	"errors"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBoundedFull(t *testing.T) {
+	const capacity = 10
+
+	myStack := Bounded.CreateBoundedStack[int](capacity)
+	if c := myStack.Cap(); c != capacity {
+		t.Errorf("Expected capacity %d, but get %d", capacity, c)
+	}
+	for i := 0; i < capacity; i++ {
+		if !myStack.TryPush(i) {
+			t.Fatalf("TryPush failed with %d of %d elements", i, capacity)
+		}
+	}
+	if myStack.TryPush(capacity) {
+		t.Errorf("TryPush succeeded on a full stack")
+	}
+	if err := myStack.PushErr(capacity); !errors.Is(err, Bounded.ErrFull) {
+		t.Errorf("Expected ErrFull from PushErr on a full stack, but get %v", err)
+	}
+	if sz := myStack.Size(); sz != capacity {
+		t.Errorf("Expected size %d, but get %d", capacity, sz)
+	}
+
+	// A popped place is free again.
+	if res, err := myStack.Pop(); err != nil || res != capacity-1 {
+		t.Errorf("Expected (%d, nil), but get (%d, %v)", capacity-1, res, err)
+	}
+	if !myStack.TryPush(capacity) {
+		t.Errorf("TryPush failed after Pop")
+	}
+	for i := capacity; i > 0; i-- {
+		if i == capacity-1 {
+			continue
+		}
+		if res, err := myStack.Pop(); err != nil || res != i {
+			t.Errorf("Expected (%d, nil), but get (%d, %v)", i, res, err)
+		}
+	}
+	if res, err := myStack.Pop(); err != nil || res != 0 {
+		t.Errorf("Expected (0, nil), but get (%d, %v)", res, err)
+	}
+
+}
+
+func TestBoundedCapacity(t *testing.T) {
+	for _, capacity := range []int{0, -1} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("CreateBoundedStack(%d) did not panic", capacity)
+				}
+			}()
+			Bounded.CreateBoundedStack[int](capacity)
+		}()
+	}
+}
+
+// TestBoundedPushWaits checks that Push on a full stack waits for a Pop
+// instead of failing.
+func TestBoundedPushWaits(t *testing.T) {
+	const capacity = 4
+
+	myStack := Bounded.CreateBoundedStack[int](capacity)
+	for i := 0; i < capacity; i++ {
+		myStack.Push(i)
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		myStack.Push(capacity)
+	}()
+	select {
+	case <-done:
+		t.Fatalf("Push on a full stack returned before a Pop")
+	case <-time.After(10 * time.Millisecond):
+	}
+	if res, err := myStack.Pop(); err != nil || res != capacity-1 {
+		t.Errorf("Expected (%d, nil), but get (%d, %v)", capacity-1, res, err)
+	}
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("Push did not take the place freed by Pop")
+	}
+	if res, ok := myStack.Peek(); !ok || res != capacity {
+		t.Errorf("Expected (%d, true), but get (%d, %t)", capacity, res, ok)
+	}
+	if err := myStack.PushErr(capacity + 1); !errors.Is(err, Bounded.ErrFull) {
+		t.Errorf("Expected ErrFull from PushErr on a full stack, but get %v", err)
+	}
+}
+
+// TestBoundedGoroutines runs producers that wait in Push against consumers
+// on a small stack, so that the places are reused many times while other
+// goroutines Peek, and checks that every value is popped exactly once.
+func TestBoundedGoroutines(t *testing.T) {
+	const goroutineCount = 8
+	const elements = 10_000
+
+	myStack := Bounded.CreateBoundedStack[int](goroutineCount)
+	seen := make([]bool, goroutineCount*elements)
+	mutex := sync.Mutex{}
+	wg := sync.WaitGroup{}
+	wg.Add(3 * goroutineCount)
+	for g := 0; g < goroutineCount; g++ {
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < elements; i++ {
+				myStack.Push(g*elements + i)
+			}
+		}(g)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < elements; i++ {
+				val, ok := myStack.TryPop()
+				for !ok {
+					runtime.Gosched()
+					val, ok = myStack.TryPop()
+				}
+				mutex.Lock()
+				if seen[val] {
+					t.Errorf("Value %d popped twice", val)
+				}
+				seen[val] = true
+				mutex.Unlock()
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 0; i < elements; i++ {
+				myStack.Peek()
+				myStack.Size()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if !myStack.IsEmpty() {
+		t.Errorf("Expected empty stack, but get size %d", myStack.Size())
+	}
+}
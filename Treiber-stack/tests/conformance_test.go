@@ -0,0 +1,57 @@
+package tests
+
+import (
+	"Treiber-stack/stacks"
+	"errors"
+	"testing"
+)
+
+// checkEmpty checks every method of the stack contract on an empty stack.
+func checkEmpty(t *testing.T, myStack stacks.Stack[int], typeStack string) {
+	t.Helper()
+	if res, err := myStack.Pop(); !errors.Is(err, stacks.ErrEmpty) || res != 0 {
+		t.Errorf("Pop of empty %s stack: expected (0, ErrEmpty), but get (%d, %v)", typeStack, res, err)
+	}
+	if res, ok := myStack.TryPop(); ok || res != 0 {
+		t.Errorf("TryPop of empty %s stack: expected (0, false), but get (%d, %t)", typeStack, res, ok)
+	}
+	if res, ok := myStack.Peek(); ok || res != 0 {
+		t.Errorf("Peek of empty %s stack: expected (0, false), but get (%d, %t)", typeStack, res, ok)
+	}
+	if !myStack.IsEmpty() {
+		t.Errorf("IsEmpty of empty %s stack returned false", typeStack)
+	}
+	if sz := myStack.Size(); sz != 0 {
+		t.Errorf("Size of empty %s stack expected 0, but get %d", typeStack, sz)
+	}
+}
+
+func TestStackContract(t *testing.T) {
+	for _, testStruct := range newTestStacks() {
+		myStack := testStruct.currStack
+		checkEmpty(t, myStack, testStruct.typeStack)
+
+		myStack.Push(1)
+		if res, ok := myStack.Peek(); !ok || res != 1 {
+			t.Errorf("Peek of %s stack: expected (1, true), but get (%d, %t)", testStruct.typeStack, res, ok)
+		}
+		if myStack.IsEmpty() {
+			t.Errorf("IsEmpty of %s stack with one element returned true", testStruct.typeStack)
+		}
+		if res, ok := myStack.TryPop(); !ok || res != 1 {
+			t.Errorf("TryPop of %s stack: expected (1, true), but get (%d, %t)", testStruct.typeStack, res, ok)
+		}
+		checkEmpty(t, myStack, testStruct.typeStack)
+
+		// Emptied after several elements, not only after one.
+		for i := 0; i < 10; i++ {
+			myStack.Push(i)
+		}
+		for i := 0; i < 10; i++ {
+			if _, err := myStack.Pop(); err != nil {
+				t.Errorf("Unexpected error in %s stack: %v", testStruct.typeStack, err)
+			}
+		}
+		checkEmpty(t, myStack, testStruct.typeStack)
+	}
+}
@@ -0,0 +1,86 @@
+package tests
+
+import (
+	"Treiber-stack/deques/ChaseLev"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestChaseLevDeque(t *testing.T) {
+	deque := ChaseLev.CreateChaseLevDeque[int]()
+	elements := 100
+	for i := 0; i < elements; i++ {
+		deque.PushBottom(i)
+	}
+	if sz := deque.Size(); sz != elements {
+		t.Errorf("Size expected %d, but get %d", elements, sz)
+	}
+
+	// The owner works with the bottom, thieves take from the top.
+	for i := 0; i < elements/2; i++ {
+		if res, ok := deque.Steal(); !ok || res != i {
+			t.Errorf("Expected %d from Steal, but get (%d, %t)", i, res, ok)
+		}
+	}
+	for i := elements - 1; i >= elements/2; i-- {
+		if res, ok := deque.PopBottom(); !ok || res != i {
+			t.Errorf("Expected %d from PopBottom, but get (%d, %t)", i, res, ok)
+		}
+	}
+
+	if _, ok := deque.PopBottom(); ok {
+		t.Error("Deque expected to be empty")
+	}
+	if _, ok := deque.Steal(); ok {
+		t.Error("Deque expected to be empty")
+	}
+}
+
+// TestChaseLevDequeSteal checks that every pushed value is taken exactly once
+// while the owner pushes and pops and thieves steal.
+func TestChaseLevDequeSteal(t *testing.T) {
+	const thievesCount = 8
+	const elements = 100_000
+
+	deque := ChaseLev.CreateChaseLevDeque[int]()
+	taken := make([]atomic.Int32, elements)
+	var stop atomic.Bool
+
+	wg := sync.WaitGroup{}
+	wg.Add(thievesCount)
+	for i := 0; i < thievesCount; i++ {
+		go func() {
+			defer wg.Done()
+			for !stop.Load() {
+				if res, ok := deque.Steal(); ok {
+					taken[res].Add(1)
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < elements; i++ {
+		deque.PushBottom(i)
+		if i%3 == 0 {
+			if res, ok := deque.PopBottom(); ok {
+				taken[res].Add(1)
+			}
+		}
+	}
+	for {
+		res, ok := deque.PopBottom()
+		if !ok {
+			break
+		}
+		taken[res].Add(1)
+	}
+	stop.Store(true)
+	wg.Wait()
+
+	for i := range taken {
+		if cnt := taken[i].Load(); cnt != 1 {
+			t.Fatalf("Value %d was taken %d times", i, cnt)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package tests
+
+import (
+	"Treiber-stack/stacks/optimizationTreiber"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+// TestEliminationKeepsValues makes pushes and pops meet in the elimination
+// array and checks that every pushed value is popped exactly once, whether it
+// went through the list or through an exchanger. Elimination happens only
+// after a failed CAS, so the test runs on several threads even on one core.
+func TestEliminationKeepsValues(t *testing.T) {
+	const goroutineCount = 8
+	const elements = 20_000
+
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(max(runtime.GOMAXPROCS(0), 4)))
+	for round := 0; round < 20; round++ {
+		myStack := optimizationTreiber.CreateBackoffTreiberStack[int]()
+		popped := make([][]int, goroutineCount)
+		wg := sync.WaitGroup{}
+		wg.Add(2 * goroutineCount)
+		for g := 0; g < goroutineCount; g++ {
+			go func(g int) {
+				defer wg.Done()
+				for i := 0; i < elements; i++ {
+					myStack.Push(g*elements + i)
+				}
+			}(g)
+			go func(g int) {
+				defer wg.Done()
+				for i := 0; i < elements; i++ {
+					if val, err := myStack.Pop(); err == nil {
+						popped[g] = append(popped[g], val)
+					}
+				}
+			}(g)
+		}
+		wg.Wait()
+
+		seen := make([]bool, goroutineCount*elements)
+		check := func(val int) {
+			if seen[val] {
+				t.Fatalf("Value %d popped twice in round %d", val, round)
+			}
+			seen[val] = true
+		}
+		for _, values := range popped {
+			for _, val := range values {
+				check(val)
+			}
+		}
+		for val, err := myStack.Pop(); err == nil; val, err = myStack.Pop() {
+			check(val)
+		}
+		for val, ok := range seen {
+			if !ok {
+				t.Fatalf("Value %d is lost in round %d", val, round)
+			}
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package tests
+
+import (
+	"Treiber-stack/stacks/optimizationTreiber"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestExchangePairs(t *testing.T) {
+	const goroutineCount = 100
+	const rounds = 50
+
+	var ex optimizationTreiber.Exchanger[int]
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	for round := 0; round < rounds; round++ {
+		got := make([]int, goroutineCount)
+		wg := sync.WaitGroup{}
+		wg.Add(goroutineCount)
+		for g := 0; g < goroutineCount; g++ {
+			go func(g int) {
+				defer wg.Done()
+				res, err := ex.Exchange(ctx, g)
+				if err != nil {
+					t.Errorf("Unexpected error in exchange of %d: %v", g, err)
+					got[g] = -1
+					return
+				}
+				got[g] = res
+			}(g)
+		}
+		wg.Wait()
+
+		for g, partner := range got {
+			if partner < 0 {
+				continue
+			}
+			if partner == g || got[partner] != g {
+				t.Fatalf("Goroutine %d got %d, but %d got %d", g, partner, partner, got[partner])
+			}
+		}
+	}
+}
+
+func TestExchangeTimeout(t *testing.T) {
+	var ex optimizationTreiber.Exchanger[int]
+	res, err := ex.ExchangeTimeout(1, time.Millisecond)
+	if !errors.Is(err, optimizationTreiber.ErrTimeout) || res != 0 {
+		t.Errorf("Expected (0, ErrTimeout) without partner, but get (%d, %v)", res, err)
+	}
+
+	// The withdrawn offer must not be taken by a later partner.
+	done := make(chan int)
+	go func() {
+		res, _ := ex.ExchangeTimeout(2, time.Second)
+		done <- res
+	}()
+	if res, err := ex.ExchangeTimeout(3, time.Second); err != nil || res != 2 {
+		t.Errorf("Expected (2, nil) from partner, but get (%d, %v)", res, err)
+	}
+	if res := <-done; res != 3 {
+		t.Errorf("Expected 3 from partner, but get %d", res)
+	}
+}
+
+func TestExchangeCancel(t *testing.T) {
+	var ex optimizationTreiber.Exchanger[string]
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(time.Millisecond)
+		cancel()
+	}()
+	if res, err := ex.Exchange(ctx, "value"); !errors.Is(err, optimizationTreiber.ErrCanceled) || res != "" {
+		t.Errorf("Expected (\"\", ErrCanceled) after cancel, but get (%q, %v)", res, err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	if _, err := ex.Exchange(ctx, "value"); !errors.Is(err, optimizationTreiber.ErrTimeout) {
+		t.Errorf("Expected ErrTimeout after deadline, but get %v", err)
+	}
+}
+
+// TestExchangeParked lets the first goroutine wait long enough to park, the
+// partner must wake it with its value.
+func TestExchangeParked(t *testing.T) {
+	var ex optimizationTreiber.Exchanger[int]
+	done := make(chan int)
+	go func() {
+		res, _ := ex.Exchange(context.Background(), 1)
+		done <- res
+	}()
+	time.Sleep(10 * time.Millisecond)
+	if res, err := ex.ExchangeTimeout(2, 10*time.Second); err != nil || res != 1 {
+		t.Errorf("Expected (1, nil) from parked partner, but get (%d, %v)", res, err)
+	}
+	if res := <-done; res != 2 {
+		t.Errorf("Expected 2 from partner, but get %d", res)
+	}
+
+	// A parked ExchangeTimeout wakes up on its timer.
+	start := time.Now()
+	if _, err := ex.ExchangeTimeout(3, 20*time.Millisecond); !errors.Is(err, optimizationTreiber.ErrTimeout) {
+		t.Errorf("Expected ErrTimeout without partner, but get %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("ExchangeTimeout of 20ms returned after %v", elapsed)
+	}
+}
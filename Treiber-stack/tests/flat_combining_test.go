@@ -0,0 +1,57 @@
+package tests
+
+import (
+	"Treiber-stack/stacks/FlatCombining"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+// TestFlatCombiningAging runs phases of many goroutines, which claim all
+// records and overflow to the lock, between long phases of one goroutine, in
+// which the idle records age out of the publication list. The records must be
+// published again, and every value must be popped exactly once.
+func TestFlatCombiningAging(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(max(runtime.GOMAXPROCS(0), 4)))
+	const goroutineCount = 64
+	const elements = 1_000
+	const phases = 3
+
+	myStack := FlatCombining.CreateFlatCombiningStack[int]()
+	popped := make([]int, phases*goroutineCount*elements)
+	for phase := 0; phase < phases; phase++ {
+		wg := sync.WaitGroup{}
+		wg.Add(goroutineCount)
+		for g := 0; g < goroutineCount; g++ {
+			go func(base int) {
+				defer wg.Done()
+				for i := 0; i < elements; i++ {
+					myStack.Push(base + i)
+					val, err := myStack.Pop()
+					if err != nil {
+						t.Errorf("Unexpected error: %v", err)
+						return
+					}
+					popped[val]++
+				}
+			}((phase*goroutineCount + g) * elements)
+		}
+		wg.Wait()
+
+		for i := 0; i < 10_000; i++ {
+			myStack.Push(-1)
+			if val, err := myStack.Pop(); err != nil || val != -1 {
+				t.Fatalf("Expected (-1, nil), but get (%d, %v)", val, err)
+			}
+		}
+	}
+
+	for val, cnt := range popped {
+		if cnt != 1 {
+			t.Fatalf("Value %d was popped %d times", val, cnt)
+		}
+	}
+	if !myStack.IsEmpty() {
+		t.Errorf("Expected empty stack, but get size %d", myStack.Size())
+	}
+}
@@ -0,0 +1,55 @@
+package tests
+
+import (
+	"Treiber-stack/forkjoin"
+	"Treiber-stack/stacks/Treiber"
+	"Treiber-stack/stacks/optimizationTreiber"
+	"testing"
+)
+
+func fibonacci(w *forkjoin.Worker, n int) int {
+	if n < 2 {
+		return n
+	}
+	var left int
+	task := w.Fork(func(w *forkjoin.Worker) {
+		left = fibonacci(w, n-1)
+	})
+	right := fibonacci(w, n-2)
+	w.Join(task)
+	return left + right
+}
+
+func TestForkJoin(t *testing.T) {
+	treiberSt := Treiber.CreateTreiberStack[*forkjoin.Task]()
+	optTreiberSt := optimizationTreiber.CreateBackoffTreiberStack[*forkjoin.Task]()
+	var tests = []struct {
+		pool     *forkjoin.Pool
+		typePool string
+	}{
+		{forkjoin.NewPool(4), "work-stealing"},
+		{forkjoin.NewPool(1), "work-stealing with one worker"},
+		{forkjoin.NewSharedStackPool(4, &treiberSt), "treiber"},
+		{forkjoin.NewSharedStackPool(4, &optTreiberSt), "optimization treiber"},
+	}
+
+	for _, testStruct := range tests {
+		for n, expected := range []int{0, 1, 1, 2, 3, 5, 8, 13, 21, 34, 55} {
+			var res int
+			testStruct.pool.Run(func(w *forkjoin.Worker) {
+				res = fibonacci(w, n)
+			})
+			if res != expected {
+				t.Errorf("Expected fibonacci(%d) = %d in %s pool, but get %d", n, expected, testStruct.typePool, res)
+			}
+		}
+
+		var res int
+		testStruct.pool.Run(func(w *forkjoin.Worker) {
+			res = fibonacci(w, 20)
+		})
+		if res != 6765 {
+			t.Errorf("Expected fibonacci(20) = 6765 in %s pool, but get %d", testStruct.typePool, res)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package tests
+
+import (
+	"Treiber-stack/linearizability"
+	"Treiber-stack/stacks"
+	"Treiber-stack/stacks/Bounded"
+	"Treiber-stack/stacks/FlatCombining"
+	"Treiber-stack/stacks/Simple"
+	"Treiber-stack/stacks/Treiber"
+	"Treiber-stack/stacks/optimizationTreiber"
+	"errors"
+	"lincheck"
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+type stackOperation = lincheck.Operation[linearizability.StackInput[int], linearizability.StackOutput[int]]
+
+func pushOp(value int, call, ret int64) stackOperation {
+	return stackOperation{
+		Input:  linearizability.StackInput[int]{Op: linearizability.StackPush, Value: value},
+		Call:   call,
+		Return: ret,
+	}
+}
+
+func popOp(value int, err error, call, ret int64) stackOperation {
+	return stackOperation{
+		Input:  linearizability.StackInput[int]{Op: linearizability.StackPop},
+		Output: linearizability.StackOutput[int]{Value: value, Err: err},
+		Call:   call,
+		Return: ret,
+	}
+}
+
+func TestCheckStack(t *testing.T) {
+	model := linearizability.StackModel[int]()
+	errEmpty := errors.New("empty")
+
+	var tests = []struct {
+		history      []stackOperation
+		linearizable bool
+		name         string
+	}{
+		{[]stackOperation{
+			pushOp(1, 1, 2), pushOp(2, 3, 4), popOp(2, nil, 5, 6), popOp(1, nil, 7, 8),
+		}, true, "sequential LIFO"},
+		{[]stackOperation{
+			pushOp(1, 1, 2), pushOp(2, 3, 4), popOp(1, nil, 5, 6),
+		}, false, "FIFO order"},
+		{[]stackOperation{
+			pushOp(1, 1, 4), pushOp(2, 2, 5), popOp(1, nil, 6, 7), popOp(2, nil, 8, 9),
+		}, true, "concurrent pushes"},
+		{[]stackOperation{
+			pushOp(1, 1, 2), popOp(0, errEmpty, 3, 4),
+		}, false, "lost push"},
+		{[]stackOperation{
+			pushOp(1, 1, 6), popOp(0, errEmpty, 2, 3), popOp(1, nil, 4, 5),
+		}, true, "pop overlapping push"},
+	}
+
+	for _, testStruct := range tests {
+		if res := lincheck.Check(model, testStruct.history); res != testStruct.linearizable {
+			t.Errorf("History %q expected linearizable = %t, but get %t", testStruct.name, testStruct.linearizable, res)
+		}
+	}
+}
+
+// recordPushAndPop runs goroutineCount goroutines doing random pushes and pops
+// on the stack and checks the recorded history.
+func recordPushAndPop(t *testing.T, myStack stacks.Stack[int], typeStack string, goroutineCount int) {
+	const opsCount = 300
+
+	recorder := linearizability.NewStackRecorder(myStack)
+	wg := sync.WaitGroup{}
+	wg.Add(goroutineCount)
+	for g := 0; g < goroutineCount; g++ {
+		client := recorder.Client()
+		go func(g int) {
+			defer wg.Done()
+			r := rand.New(rand.NewSource(int64(g)))
+			for i := 0; i < opsCount; i++ {
+				if r.Intn(2) == 0 {
+					client.Push(g*opsCount + i)
+				} else {
+					client.Pop()
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	if !lincheck.Check(linearizability.StackModel[int](), recorder.History()) {
+		t.Errorf("History of %s stack is not linearizable", typeStack)
+	}
+}
+
+func TestLinearizability(t *testing.T) {
+	simpleSt := Simple.CreateSimpleStack[int]()
+	recordPushAndPop(t, &simpleSt, "simple", 1)
+
+	treiberSt := Treiber.CreateTreiberStack[int]()
+	optTreiberSt := optimizationTreiber.CreateBackoffTreiberStack[int]()
+	flatCombiningSt := FlatCombining.CreateFlatCombiningStack[int]()
+	boundedSt := Bounded.CreateBoundedStack[int](4 * 300)
+	smallArraySt := optimizationTreiber.CreateBackoffTreiberStack[int](
+		optimizationTreiber.WithEliminationCapacity(1),
+		optimizationTreiber.WithWaitSteps(10),
+		optimizationTreiber.WithBackoff(optimizationTreiber.NewExponentialBackoff(0, 0)),
+	)
+	noEliminationSt := optimizationTreiber.CreateBackoffTreiberStack[int](
+		optimizationTreiber.WithoutElimination(),
+		optimizationTreiber.WithBackoff(optimizationTreiber.NewTimeBackoff(0, 0)),
+	)
+	var tests = []struct {
+		currStack stacks.Stack[int]
+		typeStack string
+	}{
+		{&treiberSt, "treiber"},
+		{&optTreiberSt, "optimization treiber"},
+		{&flatCombiningSt, "flat combining"},
+		{&boundedSt, "bounded"},
+		{&smallArraySt, "optimization treiber with small array"},
+		{&noEliminationSt, "optimization treiber without elimination"},
+	}
+	for _, testStruct := range tests {
+		recordPushAndPop(t, testStruct.currStack, testStruct.typeStack, 4)
+	}
+}
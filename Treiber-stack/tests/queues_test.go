@@ -0,0 +1,112 @@
+package tests
+
+import (
+	"Treiber-stack/queues"
+	"Treiber-stack/queues/MichaelScott"
+	"Treiber-stack/queues/Mutex"
+	"Treiber-stack/queues/TwoLock"
+	"errors"
+	"sync"
+	"testing"
+)
+
+type namedQueue struct {
+	currQueue queues.Queue[int]
+	typeQueue string
+}
+
+func newTestQueues() []namedQueue {
+	return []namedQueue{
+		{Mutex.CreateMutexQueue[int](), "mutex"},
+		{TwoLock.CreateTwoLockQueue[int](), "two-lock"},
+		{MichaelScott.CreateMSQueue[int](), "michael-scott"},
+	}
+}
+
+func TestEnqueueAndDequeue(t *testing.T) {
+	for _, testStruct := range newTestQueues() {
+		myQueue := testStruct.currQueue
+		elements := 100
+		for i := 0; i < elements; i++ {
+			myQueue.Enqueue(i)
+			if res, ok := myQueue.Peek(); !ok || res != 0 {
+				t.Errorf("Expected (0, true) at front of %s queue, but get (%d, %t)", testStruct.typeQueue, res, ok)
+			}
+		}
+		if sz := myQueue.Size(); sz != elements {
+			t.Errorf("Size of %s queue expected %d, but get %d", testStruct.typeQueue, elements, sz)
+		}
+
+		for i := 0; i < elements; i++ {
+			res, err := myQueue.Dequeue()
+			if err != nil {
+				t.Errorf("Unexpected error in %s queue: %v", testStruct.typeQueue, err)
+			} else if res != i {
+				t.Errorf("Expected %d at front of %s queue, but get %d", i, testStruct.typeQueue, res)
+			}
+		}
+
+		if _, err := myQueue.Dequeue(); !errors.Is(err, queues.ErrEmpty) {
+			t.Errorf("Expected ErrEmpty from empty %s queue, but get %v", testStruct.typeQueue, err)
+		}
+		if res, ok := myQueue.Peek(); ok || res != 0 {
+			t.Errorf("Peek of empty %s queue: expected (0, false), but get (%d, %t)", testStruct.typeQueue, res, ok)
+		}
+	}
+}
+
+// TestQueueGoroutines runs the concurrent scenario of TestPushGoroutines and
+// checks that the values of every producer come out in their order.
+func TestQueueGoroutines(t *testing.T) {
+	const goroutineCount = 100
+	const elements = 10_000
+
+	for _, testStruct := range newTestQueues() {
+		myStack := queues.AsStack(testStruct.currQueue)
+		wg := sync.WaitGroup{}
+		wg.Add(goroutineCount)
+		for i := 0; i < goroutineCount; i++ {
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < elements; j++ {
+					myStack.Push(i*elements + j)
+				}
+			}(i)
+		}
+		wg.Wait()
+		if sz := myStack.Size(); sz != goroutineCount*elements {
+			t.Errorf("Size of %s queue expected %d, but get %d", testStruct.typeQueue, goroutineCount*elements, sz)
+			continue
+		}
+
+		last := make([][]int, goroutineCount)
+		wg.Add(goroutineCount)
+		for i := 0; i < goroutineCount; i++ {
+			go func(i int) {
+				defer wg.Done()
+				last[i] = make([]int, goroutineCount)
+				for j := range last[i] {
+					last[i][j] = -1
+				}
+				for j := 0; j < elements; j++ {
+					val, err := myStack.Pop()
+					if err != nil {
+						t.Errorf("Unexpected error in %s queue: %v", testStruct.typeQueue, err)
+						return
+					}
+					producer, seq := val/elements, val%elements
+					if seq <= last[i][producer] {
+						t.Errorf("Consumer of %s queue got %d after %d from one producer", testStruct.typeQueue, seq, last[i][producer])
+						return
+					}
+					last[i][producer] = seq
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		if sz := myStack.Size(); sz != 0 {
+			t.Errorf("Queue %s expected to be empty, but has %d elements", testStruct.typeQueue, sz)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package tests
+
+import (
+	"Treiber-stack/reclamation"
+	"Treiber-stack/stacks"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+type testNode struct {
+	value int
+}
+
+func TestEBRGuard(t *testing.T) {
+	domain := reclamation.NewEBR[testNode](4)
+	reader := domain.Enter()
+	writer := domain.Enter()
+	node := &testNode{value: 1}
+	domain.Retire(writer, node)
+	domain.Exit(writer)
+
+	// The reader may still hold the node, so it is never reclaimed.
+	for i := 0; i < 10; i++ {
+		domain.TryAdvance()
+	}
+	if _, reclaimed := domain.Stats(); reclaimed != 0 {
+		t.Errorf("Reclaimed %d nodes while a reader is active", reclaimed)
+	}
+	for i := 0; i < 1_000; i++ {
+		if domain.Alloc() == node {
+			t.Fatal("Retired node was reused while a reader is active")
+		}
+	}
+
+	domain.Exit(reader)
+	for i := 0; i < 3; i++ {
+		if !domain.TryAdvance() {
+			t.Errorf("Epoch did not advance without readers")
+		}
+	}
+	if retired, reclaimed := domain.Stats(); retired != 1 || reclaimed != 1 {
+		t.Errorf("Expected 1 retired and 1 reclaimed node, but get %d and %d", retired, reclaimed)
+	}
+}
+
+// TestEBRGrow holds more guards than the domain has slots, Enter must add
+// slots instead of waiting, and the added ones must still block reclamation.
+func TestEBRGrow(t *testing.T) {
+	const guardsCount = 8
+
+	domain := reclamation.NewEBR[testNode](1)
+	guards := make([]reclamation.Guard, guardsCount)
+	for i := range guards {
+		guards[i] = domain.Enter()
+	}
+	writer := domain.Enter()
+	domain.Retire(writer, &testNode{value: 1})
+	domain.Exit(writer)
+
+	for i := range guards {
+		for j := 0; j < 3; j++ {
+			domain.TryAdvance()
+		}
+		if _, reclaimed := domain.Stats(); reclaimed != 0 {
+			t.Fatalf("Reclaimed %d nodes while %d readers are active", reclaimed, guardsCount-i)
+		}
+		domain.Exit(guards[i])
+	}
+	for i := 0; i < 3; i++ {
+		domain.TryAdvance()
+	}
+	if _, reclaimed := domain.Stats(); reclaimed != 1 {
+		t.Errorf("Expected 1 reclaimed node, but get %d", reclaimed)
+	}
+}
+
+// isRecycling reports whether the stack reuses popped nodes.
+func isRecycling(myStack stacks.Stack[int]) bool {
+	recycling, ok := myStack.(interface{ Recycles() bool })
+	return ok && recycling.Recycles()
+}
+
+// TestRecyclingABA pops and pushes back a few values on many goroutines, so
+// that the same nodes come back to the head again and again. A node reused
+// while another Pop holds it would make that Pop swing the head to a stale
+// next and lose or duplicate values, so the values left at the end must be
+// exactly the pushed ones.
+func TestRecyclingABA(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(max(runtime.NumCPU(), 4)))
+	const goroutineCount = 64
+	const valuesCount = 4
+	const rounds = 50_000
+
+	for _, testStruct := range filterStacks(isRecycling) {
+		myStack := testStruct.currStack
+		for i := 0; i < valuesCount; i++ {
+			myStack.Push(i)
+		}
+
+		wg := sync.WaitGroup{}
+		wg.Add(goroutineCount)
+		for g := 0; g < goroutineCount; g++ {
+			go func() {
+				defer wg.Done()
+				for i := 0; i < rounds; i++ {
+					first, err := myStack.Pop()
+					if err != nil {
+						continue
+					}
+					// Holding two values keeps the stack short, so the popped
+					// nodes come back to the head sooner.
+					if second, err := myStack.Pop(); err == nil {
+						myStack.Push(first)
+						myStack.Push(second)
+						continue
+					}
+					myStack.Push(first)
+				}
+			}()
+		}
+		wg.Wait()
+
+		// A lost node may leave a cycle, so the drain is bounded.
+		counts := make(map[int]int)
+		for i := 0; i < 2*valuesCount; i++ {
+			val, err := myStack.Pop()
+			if err != nil {
+				break
+			}
+			counts[val]++
+		}
+		for val := 0; val < valuesCount; val++ {
+			if counts[val] != 1 {
+				t.Errorf("Value %d left %d times in %s stack, expected once", val, counts[val], testStruct.typeStack)
+			}
+			delete(counts, val)
+		}
+		for val, cnt := range counts {
+			t.Errorf("Value %d that was never pushed left %d times in %s stack", val, cnt, testStruct.typeStack)
+		}
+	}
+}
+
+func TestRecyclingLinearizability(t *testing.T) {
+	for _, testStruct := range filterStacks(isRecycling) {
+		recordPushAndPop(t, testStruct.currStack, testStruct.typeStack, 4)
+	}
+}
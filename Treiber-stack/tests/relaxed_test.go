@@ -0,0 +1,156 @@
+package tests
+
+import (
+	"Treiber-stack/stacks/Relaxed"
+	"math/rand"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// popDrift pops from the stack and returns how many elements pushed after the
+// popped one are still in reference, the strict stack of the pushed values.
+func popDrift(t *testing.T, myStack *Relaxed.KLIFOStack[int], reference *[]int) int {
+	val, err := myStack.Pop()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	ref := *reference
+	for i := len(ref) - 1; i >= 0; i-- {
+		if ref[i] == val {
+			*reference = append(ref[:i], ref[i+1:]...)
+			return len(ref) - 1 - i
+		}
+	}
+	t.Fatalf("Popped %d, which is not in the stack", val)
+	return 0
+}
+
+func TestKLIFODrift(t *testing.T) {
+	for _, k := range []int{0, 1, 3, 8} {
+		myStack := Relaxed.CreateKLIFOStack[int](k)
+		r := rand.New(rand.NewSource(int64(k)))
+		var reference []int
+		maxDrift, sumDrift, pops := 0, 0, 0
+		for i := 0; i < 100_000; i++ {
+			if len(reference) == 0 || r.Intn(2) == 0 {
+				myStack.Push(i)
+				reference = append(reference, i)
+				continue
+			}
+			drift := popDrift(t, &myStack, &reference)
+			maxDrift, sumDrift, pops = max(maxDrift, drift), sumDrift+drift, pops+1
+		}
+		for len(reference) > 0 {
+			drift := popDrift(t, &myStack, &reference)
+			maxDrift, sumDrift, pops = max(maxDrift, drift), sumDrift+drift, pops+1
+		}
+		if _, err := myStack.Pop(); err == nil {
+			t.Errorf("Stack with k = %d expected to be empty", k)
+		}
+
+		t.Logf("k = %d: max drift %d, mean drift %.2f", k, maxDrift, float64(sumDrift)/float64(pops))
+		if maxDrift > k {
+			t.Errorf("Drift of stack with k = %d reached %d", k, maxDrift)
+		}
+	}
+}
+
+// TestKLIFOGoroutines measures the drift under concurrent pushes and checks
+// that every value is popped exactly once.
+func TestKLIFOGoroutines(t *testing.T) {
+	const goroutineCount = 8
+	const elements = 10_000
+	const k = 4
+
+	myStack := Relaxed.CreateKLIFOStack[int](k)
+	wg := sync.WaitGroup{}
+	wg.Add(goroutineCount)
+	for g := 0; g < goroutineCount; g++ {
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < elements; i++ {
+				myStack.Push(g*elements + i)
+			}
+		}(g)
+	}
+	wg.Wait()
+	if sz := myStack.Size(); sz != goroutineCount*elements {
+		t.Fatalf("Size expected %d, but get %d", goroutineCount*elements, sz)
+	}
+
+	// Every producer pushed its values in ascending order, so a strict stack
+	// gives every popper the values of one producer in descending order.
+	popped := make([]int, goroutineCount*elements)
+	inversions := make([]int, goroutineCount)
+	wg.Add(goroutineCount)
+	for g := 0; g < goroutineCount; g++ {
+		go func(g int) {
+			defer wg.Done()
+			last := make([]int, goroutineCount)
+			for i := range last {
+				last[i] = elements
+			}
+			for i := 0; i < elements; i++ {
+				val, err := myStack.Pop()
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+					return
+				}
+				popped[val]++
+				producer, seq := val/elements, val%elements
+				if seq > last[producer] {
+					inversions[g]++
+				}
+				last[producer] = seq
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	total := 0
+	for _, cnt := range inversions {
+		total += cnt
+	}
+	t.Logf("k = %d: %.2f%% of pops are out of order", k, 100*float64(total)/float64(goroutineCount*elements))
+
+	for val, cnt := range popped {
+		if cnt != 1 {
+			t.Fatalf("Value %d was popped %d times", val, cnt)
+		}
+	}
+	if _, err := myStack.Pop(); err == nil {
+		t.Error("Stack expected to be empty")
+	}
+}
+
+// TestKLIFONoFalseEmpty makes every goroutine pop only after its own push, so
+// the stack is never empty during a Pop and ErrEmpty is always wrong.
+func TestKLIFONoFalseEmpty(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(max(runtime.GOMAXPROCS(0), 4)))
+	const goroutineCount = 8
+	const rounds = 200_000
+	const k = 3
+
+	myStack := Relaxed.CreateKLIFOStack[int](k)
+	var falseEmpty atomic.Int64
+	wg := sync.WaitGroup{}
+	wg.Add(goroutineCount)
+	for g := 0; g < goroutineCount; g++ {
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < rounds; i++ {
+				myStack.Push(g)
+				for _, err := myStack.Pop(); err != nil; _, err = myStack.Pop() {
+					falseEmpty.Add(1)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	if cnt := falseEmpty.Load(); cnt != 0 {
+		t.Errorf("Pop returned ErrEmpty %d times while the stack had elements", cnt)
+	}
+}
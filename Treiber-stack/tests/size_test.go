@@ -0,0 +1,90 @@
+package tests
+
+import (
+	"Treiber-stack/stacks"
+	"sync"
+	"testing"
+)
+
+type sizedStack interface {
+	Push(int)
+	Pop() (int, error)
+	PushAll(items ...int)
+	PopN(n int) []int
+	Drain() []int
+	Size() int
+	ExactSize() int
+}
+
+func isSized(myStack stacks.Stack[int]) bool {
+	_, ok := myStack.(sizedStack)
+	return ok
+}
+
+func checkSize(t *testing.T, myStack sizedStack, typeStack string, expected int) {
+	t.Helper()
+	if sz := myStack.Size(); sz != expected {
+		t.Errorf("Size of %s stack expected %d, but get %d", typeStack, expected, sz)
+	}
+	if sz := myStack.ExactSize(); sz != expected {
+		t.Errorf("ExactSize of %s stack expected %d, but get %d", typeStack, expected, sz)
+	}
+}
+
+func TestSizeModes(t *testing.T) {
+	for _, testStruct := range filterStacks(isSized) {
+		myStack := testStruct.currStack.(sizedStack)
+		checkSize(t, myStack, testStruct.typeStack, 0)
+		for i := 0; i < 10; i++ {
+			myStack.Push(i)
+		}
+		checkSize(t, myStack, testStruct.typeStack, 10)
+		myStack.PushAll(1, 2, 3)
+		checkSize(t, myStack, testStruct.typeStack, 13)
+		myStack.Pop()
+		myStack.PopN(4)
+		checkSize(t, myStack, testStruct.typeStack, 8)
+		myStack.Drain()
+		checkSize(t, myStack, testStruct.typeStack, 0)
+		myStack.Pop()
+		checkSize(t, myStack, testStruct.typeStack, 0)
+	}
+}
+
+// TestSizeGoroutines checks the bounds of both sizes while goroutines push
+// and pop, and that they agree once the goroutines finish.
+func TestSizeGoroutines(t *testing.T) {
+	const goroutineCount = 8
+	const elements = 5_000
+
+	for _, testStruct := range filterStacks(isSized) {
+		myStack := testStruct.currStack.(sizedStack)
+		wg := sync.WaitGroup{}
+		wg.Add(2 * goroutineCount)
+		for g := 0; g < goroutineCount; g++ {
+			go func(g int) {
+				defer wg.Done()
+				for i := 0; i < elements; i++ {
+					myStack.Push(g*elements + i)
+					if i%2 == 0 {
+						myStack.Pop()
+					}
+				}
+			}(g)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < elements/10; i++ {
+					if sz := myStack.Size(); sz < 0 || sz > goroutineCount*elements {
+						t.Errorf("Size of %s stack out of bounds: %d", testStruct.typeStack, sz)
+					}
+					if sz := myStack.ExactSize(); sz < 0 || sz > goroutineCount*elements {
+						t.Errorf("ExactSize of %s stack out of bounds: %d", testStruct.typeStack, sz)
+					}
+				}
+			}()
+		}
+		wg.Wait()
+
+		checkSize(t, myStack, testStruct.typeStack, goroutineCount*elements/2)
+	}
+}
@@ -2,17 +2,74 @@ package tests
 
 import (
 	"Treiber-stack/stacks"
+	"Treiber-stack/stacks/Bounded"
+	"Treiber-stack/stacks/FlatCombining"
+	"Treiber-stack/stacks/Relaxed"
 	"Treiber-stack/stacks/Simple"
 	"Treiber-stack/stacks/Treiber"
 	"Treiber-stack/stacks/optimizationTreiber"
+	"errors"
 	"sync"
 	"testing"
 )
 
+// namedStack is a stack under test. Tests that need more than stacks.Stack
+// pick the stacks by a type assertion on currStack, see filterStacks.
+type namedStack struct {
+	currStack stacks.Stack[int]
+	typeStack string
+}
+
+// newTestStacks returns a new instance of every stack.
+func newTestStacks() []namedStack {
+	simpleSt := Simple.CreateSimpleStack[int]()
+	treiberSt := Treiber.CreateTreiberStack[int]()
+	recyclingSt := Treiber.CreateRecyclingTreiberStack[int]()
+	optTreiberSt := optimizationTreiber.CreateBackoffTreiberStack[int]()
+	optRecyclingSt := optimizationTreiber.CreateBackoffTreiberStack[int](optimizationTreiber.WithNodeRecycling())
+	noEliminationSt := optimizationTreiber.CreateBackoffTreiberStack[int](
+		optimizationTreiber.WithNodeRecycling(),
+		optimizationTreiber.WithoutElimination(),
+	)
+	flatCombiningSt := FlatCombining.CreateFlatCombiningStack[int]()
+	relaxedSt := Relaxed.CreateKLIFOStack[int](3)
+	boundedSt := Bounded.CreateBoundedStack[int](1 << 16)
+	return []namedStack{
+		{&simpleSt, "simple"},
+		{&treiberSt, "treiber"},
+		{&recyclingSt, "recycling treiber"},
+		{&optTreiberSt, "optimization treiber"},
+		{&optRecyclingSt, "recycling optimization treiber"},
+		{&noEliminationSt, "recycling optimization treiber without elimination"},
+		{&flatCombiningSt, "flat combining"},
+		{&relaxedSt, "relaxed"},
+		{&boundedSt, "bounded"},
+	}
+}
+
+// filterStacks returns the stacks of newTestStacks for which keep is true.
+func filterStacks(keep func(myStack stacks.Stack[int]) bool) []namedStack {
+	var res []namedStack
+	for _, testStruct := range newTestStacks() {
+		if keep(testStruct.currStack) {
+			res = append(res, testStruct)
+		}
+	}
+	return res
+}
+
+// isConcurrent reports whether the stack may be used by several goroutines,
+// SimpleStack is the only one that may not.
+func isConcurrent(myStack stacks.Stack[int]) bool {
+	_, ok := myStack.(*Simple.SimpleStack[int])
+	return !ok
+}
+
 func TestPopAndPush(t *testing.T) {
 	simpleSt := Simple.CreateSimpleStack[int]()
 	treiberSt := Treiber.CreateTreiberStack[int]()
 	optTreiberSt := optimizationTreiber.CreateBackoffTreiberStack[int]()
+	flatCombiningSt := FlatCombining.CreateFlatCombiningStack[int]()
 	var tests = []struct {
 		currStack stacks.Stack[int]
 		typeStack string
@@ -20,6 +77,7 @@ func TestPopAndPush(t *testing.T) {
 		{&simpleSt, "simple"},
 		{&treiberSt, "treiber"},
 		{&optTreiberSt, "optimization treiber"},
+		{&flatCombiningSt, "flat combining"},
 	}
 
 	for _, testStruct := range tests {
@@ -39,8 +97,8 @@ func TestPopAndPush(t *testing.T) {
 		}
 
 		_, err := myStack.Pop()
-		if err == nil {
-			t.Error("Stack expected to be empty")
+		if !errors.Is(err, stacks.ErrEmpty) {
+			t.Errorf("Expected ErrEmpty from empty %s stack, but get %v", testStruct.typeStack, err)
 		}
 	}
 }
@@ -49,6 +107,7 @@ func TestPush(t *testing.T) {
 	simpleSt := Simple.CreateSimpleStack[int]()
 	treiberSt := Treiber.CreateTreiberStack[int]()
 	optTreiberSt := optimizationTreiber.CreateBackoffTreiberStack[int]()
+	flatCombiningSt := FlatCombining.CreateFlatCombiningStack[int]()
 	var tests = []struct {
 		currStack stacks.Stack[int]
 		typeStack string
@@ -56,6 +115,7 @@ func TestPush(t *testing.T) {
 		{&simpleSt, "simple"},
 		{&treiberSt, "treiber"},
 		{&optTreiberSt, "optimization treiber"},
+		{&flatCombiningSt, "flat combining"},
 	}
 
 	for _, testStruct := range tests {
@@ -63,8 +123,8 @@ func TestPush(t *testing.T) {
 		elements := 100
 		for i := 0; i < elements; i++ {
 			myStack.Push(i)
-			res := myStack.Peek()
-			if res != i {
+			res, ok := myStack.Peek()
+			if !ok || res != i {
 				t.Errorf("Expected %d on top of %s stack, but get %d", elements-1-i, testStruct.typeStack, res)
 			}
 		}
@@ -77,12 +137,16 @@ func TestPush(t *testing.T) {
 func TestPushGoroutines(t *testing.T) {
 	treiberSt := Treiber.CreateTreiberStack[int]()
 	optTreiberSt := optimizationTreiber.CreateBackoffTreiberStack[int]()
+	flatCombiningSt := FlatCombining.CreateFlatCombiningStack[int]()
+	relaxedSt := Relaxed.CreateKLIFOStack[int](4)
 	var tests = []struct {
 		currStack stacks.Stack[int]
 		typeStack string
 	}{
 		{&treiberSt, "treiber"},
 		{&optTreiberSt, "optimization treiber"},
+		{&flatCombiningSt, "flat combining"},
+		{&relaxedSt, "relaxed"},
 	}
 	for _, testStruct := range tests {
 		myStack := testStruct.currStack
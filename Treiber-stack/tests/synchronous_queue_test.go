@@ -0,0 +1,169 @@
+package tests
+
+import (
+	"Treiber-stack/stacks/optimizationTreiber"
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSynchronousQueuePairs(t *testing.T) {
+	const goroutineCount = 50
+	const opsCount = 200
+
+	queue := optimizationTreiber.NewSynchronousQueue[int]()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	taken := make([][]int, goroutineCount)
+	wg := sync.WaitGroup{}
+	wg.Add(2 * goroutineCount)
+	for g := 0; g < goroutineCount; g++ {
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < opsCount; i++ {
+				if err := queue.Put(ctx, g*opsCount+i); err != nil {
+					t.Errorf("Unexpected error in Put: %v", err)
+					return
+				}
+			}
+		}(g)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < opsCount; i++ {
+				value, err := queue.Take(ctx)
+				if err != nil {
+					t.Errorf("Unexpected error in Take: %v", err)
+					return
+				}
+				taken[g] = append(taken[g], value)
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	seen := make([]bool, goroutineCount*opsCount)
+	for _, values := range taken {
+		for _, value := range values {
+			if seen[value] {
+				t.Fatalf("Value %d was taken twice", value)
+			}
+			seen[value] = true
+		}
+	}
+	for value, ok := range seen {
+		if !ok {
+			t.Fatalf("Value %d was not taken", value)
+		}
+	}
+}
+
+// awaitWaiting waits until count calls wait in the queue.
+func awaitWaiting(t *testing.T, queue *optimizationTreiber.SynchronousQueue[int], count int) {
+	deadline := time.Now().Add(10 * time.Second)
+	for queue.Waiting() != count {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d waiting calls, but get %d", count, queue.Waiting())
+		}
+		runtime.Gosched()
+	}
+}
+
+func TestSynchronousQueueFIFO(t *testing.T) {
+	const putCount = 20
+
+	queue := optimizationTreiber.NewSynchronousQueue[int]()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Every putter starts after the previous one waits, so they wait in
+	// this order.
+	for i := 0; i < putCount; i++ {
+		go queue.Put(ctx, i)
+		awaitWaiting(t, queue, i+1)
+	}
+	for i := 0; i < putCount; i++ {
+		if value, err := queue.Take(ctx); err != nil || value != i {
+			t.Errorf("Expected (%d, nil) from Take, but get (%d, %v)", i, value, err)
+		}
+	}
+
+	// The same for takers.
+	results := make(chan int)
+	for i := 0; i < putCount; i++ {
+		go func(i int) {
+			value, _ := queue.Take(ctx)
+			results <- value - i
+		}(i)
+		awaitWaiting(t, queue, i+1)
+	}
+	for i := 0; i < putCount; i++ {
+		if err := queue.Put(ctx, i); err != nil {
+			t.Errorf("Unexpected error in Put: %v", err)
+		}
+		if diff := <-results; diff != 0 {
+			t.Errorf("Taker got a value of another taker, shifted by %d", diff)
+		}
+	}
+}
+
+func TestSynchronousQueueCancel(t *testing.T) {
+	queue := optimizationTreiber.NewSynchronousQueue[int]()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	if value, err := queue.Take(ctx); !errors.Is(err, optimizationTreiber.ErrTimeout) || value != 0 {
+		t.Errorf("Expected (0, ErrTimeout) from empty queue, but get (%d, %v)", value, err)
+	}
+	// The canceled reservation must not take the value.
+	ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	if err := queue.Put(ctx, 1); !errors.Is(err, optimizationTreiber.ErrTimeout) {
+		t.Errorf("Expected ErrTimeout from Put without takers, but get %v", err)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	if err := queue.Put(ctx, 2); !errors.Is(err, optimizationTreiber.ErrCanceled) {
+		t.Errorf("Expected ErrCanceled from Put with canceled context, but get %v", err)
+	}
+
+	// A value put after the cancellations is still delivered.
+	go queue.Put(context.Background(), 3)
+	if value, err := queue.Take(context.Background()); err != nil || value != 3 {
+		t.Errorf("Expected (3, nil) from Take, but get (%d, %v)", value, err)
+	}
+}
+
+// TestSynchronousQueueUnlink cancels every other waiting putter, the takers
+// must still get the values of the rest in order.
+func TestSynchronousQueueUnlink(t *testing.T) {
+	const putCount = 20
+
+	queue := optimizationTreiber.NewSynchronousQueue[int]()
+	cancels := make([]context.CancelFunc, putCount)
+	for i := 0; i < putCount; i++ {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancels[i] = cancel
+		go queue.Put(ctx, i)
+		awaitWaiting(t, queue, i+1)
+	}
+	for i := 1; i < putCount; i += 2 {
+		cancels[i]()
+	}
+	awaitWaiting(t, queue, putCount/2)
+
+	for i := 0; i < putCount; i += 2 {
+		if value, err := queue.Take(context.Background()); err != nil || value != i {
+			t.Errorf("Expected (%d, nil) from Take, but get (%d, %v)", i, value, err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	if value, err := queue.Take(ctx); !errors.Is(err, optimizationTreiber.ErrTimeout) {
+		t.Errorf("Expected ErrTimeout after the waiting putters, but get (%d, %v)", value, err)
+	}
+}
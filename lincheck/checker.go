@@ -0,0 +1,177 @@
+// Package lincheck records histories of concurrent objects and checks them
+// for linearizability. The BST and Treiber-stack modules use it through a
+// replace directive, their linearizability packages only add the models.
+package lincheck
+
+import (
+	"encoding/binary"
+	"sort"
+)
+
+// Operation is a completed call from a recorded history: its input, output
+// and the logical times of its invocation and response.
+type Operation[I, O any] struct {
+	ClientID int
+	Input    I
+	Output   O
+	Call     int64
+	Return   int64
+}
+
+// Model is the sequential specification of an object with states S.
+type Model[S, I, O any] struct {
+	// Partition optionally splits a history into independent sub-histories,
+	// each of which must be linearizable on its own.
+	Partition func(history []Operation[I, O]) [][]Operation[I, O]
+	Init      func() S
+	// Step applies input to state and reports whether output is a possible
+	// result of it, together with the new state.
+	Step  func(state S, input I, output O) (bool, S)
+	Equal func(a, b S) bool
+}
+
+// Check reports whether the history is linearizable with respect to model.
+//
+// It is the search of Wing and Gong with the memoization of Lowe: the calls
+// and returns are kept in a list ordered by time, and the search tries to
+// linearize every call that is not preceded by the return of a pending call.
+// A linearized call is lifted out of the list together with its return, and
+// the search backtracks when it meets a return whose call is not linearized
+// yet. Pairs of a set of linearized calls and a state that were already
+// explored are skipped.
+func Check[S, I, O any](model Model[S, I, O], history []Operation[I, O]) bool {
+	partitions := [][]Operation[I, O]{history}
+	if model.Partition != nil {
+		partitions = model.Partition(history)
+	}
+	for _, partition := range partitions {
+		if !checkPartition(model, partition) {
+			return false
+		}
+	}
+	return true
+}
+
+type entry struct {
+	id     int
+	isCall bool
+	time   int64
+	// match is the return of a call.
+	match      *entry
+	prev, next *entry
+}
+
+// makeEntries returns the sentinel head of the list of calls and returns of
+// history ordered by time. Calls go first at equal times, so such operations
+// are treated as concurrent.
+func makeEntries[I, O any](history []Operation[I, O]) *entry {
+	entries := make([]*entry, 0, 2*len(history))
+	for id, op := range history {
+		ret := &entry{id: id, time: op.Return}
+		entries = append(entries, &entry{id: id, isCall: true, time: op.Call, match: ret}, ret)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].time != entries[j].time {
+			return entries[i].time < entries[j].time
+		}
+		return entries[i].isCall && !entries[j].isCall
+	})
+
+	head := &entry{}
+	prev := head
+	for _, e := range entries {
+		prev.next, e.prev = e, prev
+		prev = e
+	}
+	return head
+}
+
+// lift removes the call e and its return from the list.
+func (e *entry) lift() {
+	e.prev.next = e.next
+	e.next.prev = e.prev
+	ret := e.match
+	ret.prev.next = ret.next
+	if ret.next != nil {
+		ret.next.prev = ret.prev
+	}
+}
+
+// unlift puts back the call e and its return removed by lift.
+func (e *entry) unlift() {
+	ret := e.match
+	ret.prev.next = ret
+	if ret.next != nil {
+		ret.next.prev = ret
+	}
+	e.prev.next = e
+	e.next.prev = e
+}
+
+type bitset []uint64
+
+func (b bitset) set(i int)   { b[i/64] |= 1 << (i % 64) }
+func (b bitset) clear(i int) { b[i/64] &^= 1 << (i % 64) }
+
+func (b bitset) key() string {
+	buf := make([]byte, 8*len(b))
+	for i, word := range b {
+		binary.LittleEndian.PutUint64(buf[8*i:], word)
+	}
+	return string(buf)
+}
+
+func checkPartition[S, I, O any](model Model[S, I, O], history []Operation[I, O]) bool {
+	type frame struct {
+		call  *entry
+		state S
+	}
+
+	head := makeEntries(history)
+	linearized := make(bitset, (len(history)+63)/64)
+	cache := make(map[string][]S)
+	var calls []frame
+
+	seen := func(key string, state S) bool {
+		for _, cached := range cache[key] {
+			if model.Equal(cached, state) {
+				return true
+			}
+		}
+		return false
+	}
+
+	state := model.Init()
+	e := head.next
+	for head.next != nil {
+		if !e.isCall {
+			// The return of a pending call: backtrack to the last linearized one.
+			if len(calls) == 0 {
+				return false
+			}
+			top := calls[len(calls)-1]
+			calls = calls[:len(calls)-1]
+			state = top.state
+			linearized.clear(top.call.id)
+			top.call.unlift()
+			e = top.call.next
+			continue
+		}
+
+		op := history[e.id]
+		if ok, newState := model.Step(state, op.Input, op.Output); ok {
+			linearized.set(e.id)
+			if key := linearized.key(); !seen(key, newState) {
+				cache[key] = append(cache[key], newState)
+				calls = append(calls, frame{call: e, state: state})
+				state = newState
+				e.lift()
+				e = head.next
+				continue
+			}
+			linearized.clear(e.id)
+		}
+		e = e.next
+	}
+	return true
+}
@@ -0,0 +1,3 @@
+module lincheck
+
+go 1.21.5
@@ -0,0 +1,56 @@
+package lincheck
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Recorder collects the history of a concurrent run. Every goroutine records
+// through its own Client, so recording adds no contention besides the shared
+// logical clock that stamps invocations and responses.
+type Recorder[I, O any] struct {
+	clock   atomic.Int64
+	mutex   sync.Mutex
+	clients []*Client[I, O]
+}
+
+// Client is the operation log of one goroutine.
+type Client[I, O any] struct {
+	id       int
+	recorder *Recorder[I, O]
+	ops      []Operation[I, O]
+}
+
+// NewClient returns the log of a new client. A client must not be used by
+// several goroutines at once.
+func (r *Recorder[I, O]) NewClient() *Client[I, O] {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	client := &Client[I, O]{id: len(r.clients), recorder: r}
+	r.clients = append(r.clients, client)
+	return client
+}
+
+// Do records the call of f with input, stamping its invocation right before
+// and its response right after f.
+func (c *Client[I, O]) Do(input I, f func() O) O {
+	call := c.recorder.clock.Add(1)
+	output := f()
+	ret := c.recorder.clock.Add(1)
+	c.ops = append(c.ops, Operation[I, O]{ClientID: c.id, Input: input, Output: output, Call: call, Return: ret})
+	return output
+}
+
+// History returns the operations of all clients. It must be called after
+// every client is done.
+func (r *Recorder[I, O]) History() []Operation[I, O] {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var history []Operation[I, O]
+	for _, client := range r.clients {
+		history = append(history, client.ops...)
+	}
+	return history
+}